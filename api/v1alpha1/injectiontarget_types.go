@@ -0,0 +1,63 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// InjectionTargetSpec describes where bindings are injected into workloads of a kind
+// that has no built-in target injector.
+type InjectionTargetSpec struct {
+	// APIVersion of the workloads, e.g. "serving.knative.dev/v1".
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the workloads, e.g. "Service".
+	Kind string `json:"kind"`
+
+	// PodSpecPath is the dot-separated path of the pod spec in the workloads, e.g.
+	// "spec.template.spec" or "spec.podSpec". The pod template annotations rolling the pods
+	// on secret changes are only set if the path ends with ".spec" of a pod template.
+	PodSpecPath string `json:"podSpecPath"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="APIVersion",type=string,JSONPath=`.spec.apiVersion`
+// +kubebuilder:printcolumn:name="Kind",type=string,JSONPath=`.spec.kind`
+// +kubebuilder:printcolumn:name="PodSpecPath",type=string,JSONPath=`.spec.podSpecPath`
+
+// InjectionTarget is the Schema for the injectiontargets API. The target injectors of the
+// InjectionTargets are registered when the injector starts.
+type InjectionTarget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec InjectionTargetSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// InjectionTargetList contains a list of InjectionTarget
+type InjectionTargetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []InjectionTarget `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&InjectionTarget{}, &InjectionTargetList{})
+}
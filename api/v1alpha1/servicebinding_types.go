@@ -16,6 +16,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -39,8 +40,30 @@ type Binding struct {
 	To DataTarget `json:"to,omitempty"`
 
 	ContainerSelector *ContainerSelector `json:"containerSelector,omitempty"`
+
+	// FailurePolicy defines how failures to resolve or inject this binding are handled.
+	// Fail rejects the workload, Ignore admits it without this binding. Defer admits it
+	// without this binding until its source resolves, then injects it. Defaults to Fail.
+	// +kubebuilder:validation:Enum=Fail;Ignore;Defer
+	FailurePolicy FailurePolicyType `json:"failurePolicy,omitempty"`
 }
 
+// FailurePolicyType specifies how a binding that cannot be injected is handled.
+type FailurePolicyType string
+
+const (
+	// FailurePolicyFail rejects the workload if the binding cannot be injected.
+	FailurePolicyFail FailurePolicyType = "Fail"
+
+	// FailurePolicyIgnore admits the workload without the binding if it cannot be injected.
+	FailurePolicyIgnore FailurePolicyType = "Ignore"
+
+	// FailurePolicyDefer admits the workload without the binding while its source cannot be
+	// resolved, e.g. the field named by NameFromField is not populated yet, and injects the
+	// binding into the workload once the source resolves.
+	FailurePolicyDefer FailurePolicyType = "Defer"
+)
+
 type ContainerSelector struct {
 	ByNames []string `json:"byNames,omitempty"`
 }
@@ -48,7 +71,24 @@ type ContainerSelector struct {
 type DataSource struct {
 	Secret *SecretSource `json:"secret,omitempty"`
 
+	ConfigMap *ConfigMapSource `json:"configMap,omitempty"`
+
+	// Service indicates the Service whose endpoint is injected, through a ConfigMap
+	// managed by the ServiceBinding.
+	Service *ServiceSource `json:"service,omitempty"`
+
+	// Template indicates the templates rendered over other sources, injected through a
+	// Secret managed by the ServiceBinding.
+	Template *TemplateSource `json:"template,omitempty"`
+
 	Volume *VolumeSource `json:"volume,omitempty"`
+
+	// DownwardAPI indicates fields of the pod to project into files. Only file targets apply.
+	DownwardAPI *DownwardAPISource `json:"downwardAPI,omitempty"`
+
+	// ServiceAccountToken indicates a token of the pod service account to project into a file.
+	// Only file targets apply.
+	ServiceAccountToken *ServiceAccountTokenSource `json:"serviceAccountToken,omitempty"`
 }
 
 type SecretSource struct {
@@ -57,6 +97,16 @@ type SecretSource struct {
 
 	// Name of the secret.
 	Name string `json:"name,omitempty"`
+
+	// Namespace of the secret, if other than the namespace of the ServiceBinding. The secret is
+	// mirrored into the namespace of the ServiceBinding if its `secret.core.oam.dev/mirror-namespaces`
+	// annotation lists that namespace. The NameFromField object is read from the namespace of the
+	// ServiceBinding.
+	Namespace string `json:"namespace,omitempty"`
+
+	// RolloutOnChange indicates whether to roll the workload when the secret data changes,
+	// by annotating its pod template with a checksum of the data.
+	RolloutOnChange bool `json:"rolloutOnChange,omitempty"`
 }
 
 type SecretNameFromField struct {
@@ -69,10 +119,76 @@ type SecretNameFromField struct {
 	// Name of the referenced workload.
 	Name string `json:"name,omitempty"`
 
-	// The path of the field whose value is the secret name. E.g. ".status.secret".
+	// The JSONPath of the field whose value is the name of the source, in kubectl syntax.
+	// E.g. ".status.secret" or `{.status.outputs[?(@.name=="db")].value}`.
 	FieldPath string `json:"fieldPath,omitempty"`
 }
 
+type ConfigMapSource struct {
+	// NameFromField indicates the object field where the configmap name is written.
+	NameFromField *SecretNameFromField `json:"nameFromField,omitempty"`
+
+	// Name of the configmap.
+	Name string `json:"name,omitempty"`
+}
+
+// ServiceSource resolves the endpoint of a Service into the keys `host`, `port`,
+// `port_<name>` for each named port, and `scheme` and `url` if a scheme is given.
+type ServiceSource struct {
+	// NameFromField indicates the object field where the service name is written.
+	NameFromField *SecretNameFromField `json:"nameFromField,omitempty"`
+
+	// Name of the service.
+	Name string `json:"name,omitempty"`
+
+	// Scheme of the service URL, e.g. "http".
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// TemplateSource renders Go templates over the keys of secrets and configmaps and the
+// fields of objects, e.g. into a connection string.
+type TemplateSource struct {
+	// Inputs are the data the templates are rendered over.
+	Inputs []TemplateInput `json:"inputs,omitempty"`
+
+	// Data maps the keys of the managed secret to the Go templates rendering their value,
+	// e.g. `postgres://{{ .db.username }}:{{ .db.password }}@{{ .host }}/app`.
+	Data map[string]string `json:"data"`
+}
+
+// TemplateInput is a named input of templates, read from one of Secret, ConfigMap or Field.
+type TemplateInput struct {
+	// Name of the input in the templates.
+	Name string `json:"name"`
+
+	// Secret whose keys are the input.
+	Secret *SecretSource `json:"secret,omitempty"`
+
+	// ConfigMap whose keys are the input.
+	ConfigMap *ConfigMapSource `json:"configMap,omitempty"`
+
+	// Field of an object whose value is the input.
+	Field *SecretNameFromField `json:"field,omitempty"`
+}
+
+// DownwardAPISource projects fields of the pod into files.
+type DownwardAPISource struct {
+	// Items are the files to project the fields into.
+	Items []corev1.DownwardAPIVolumeFile `json:"items"`
+}
+
+// ServiceAccountTokenSource projects a token of the pod service account into a file.
+type ServiceAccountTokenSource struct {
+	// Audience of the token. Defaults to the identifier of the API server.
+	Audience string `json:"audience,omitempty"`
+
+	// ExpirationSeconds is the requested duration of validity of the token.
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+
+	// Path of the file to project the token into, relative to the mount path.
+	Path string `json:"path"`
+}
+
 type VolumeSource struct {
 	// PVCName indicates the name of the PVC as the volume source to inject.
 	PVCName string `json:"pvcName,omitempty"`
@@ -80,11 +196,70 @@ type VolumeSource struct {
 
 // Target defines what target objects to inject the binding data to.
 type DataTarget struct {
-	// The path of the file where the data source is mounted.
+	// The path of the file where the data source is mounted. Sources of bindings sharing the
+	// path are merged into a projected volume.
 	FilePath string `json:"filePath,omitempty"`
 
+	// Items selects the keys of a secret or configmap source to mount and their paths, relative
+	// to the mount path, or to SubDirectory if set.
+	Items []corev1.KeyToPath `json:"items,omitempty"`
+
+	// SubDirectory of the mount path the source is projected into, through a projected volume.
+	// Secret and configmap sources require Items.
+	SubDirectory string `json:"subDirectory,omitempty"`
+
+	// SubPath mounts a single path of the source, e.g. a key, as the file at FilePath instead of
+	// the whole source as a directory. Files mounted with a sub-path are not updated when the
+	// source changes.
+	SubPath string `json:"subPath,omitempty"`
+
+	// ReadOnly mounts the source read-only.
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// DefaultMode of the files of a secret or configmap source, e.g. 0400. Defaults to 0644.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=511
+	DefaultMode *int32 `json:"defaultMode,omitempty"`
+
 	// Env indicates whether to inject all `K=V` pairs from data source into environment variables.
 	Env bool `json:"env,omitempty"`
+
+	// Prefix is prepended to the names of the environment variables injected by Env.
+	Prefix string `json:"prefix,omitempty"`
+
+	// EnvMappings indicates the keys of the data source to inject into individual environment variables.
+	EnvMappings []EnvMapping `json:"envMappings,omitempty"`
+
+	// BindingRoot indicates to mount the data source following the Service Binding for
+	// Kubernetes specification, under `$SERVICE_BINDING_ROOT/<name>`.
+	BindingRoot *BindingRootTarget `json:"bindingRoot,omitempty"`
+}
+
+// BindingRootTarget mounts the data source in the directory of a binding under
+// `$SERVICE_BINDING_ROOT`, with the `type` and `provider` entries of the specification. The
+// data is copied into a Secret managed by the ServiceBinding. SERVICE_BINDING_ROOT is set to
+// `/bindings` in the containers that do not set it.
+type BindingRootTarget struct {
+	// Name of the binding directory.
+	Name string `json:"name"`
+
+	// Type of the binding, e.g. "postgresql". Required unless the data source has a `type` key.
+	Type string `json:"type,omitempty"`
+
+	// Provider of the binding, e.g. "bitnami".
+	Provider string `json:"provider,omitempty"`
+}
+
+// EnvMapping maps a key of the data source to an environment variable.
+type EnvMapping struct {
+	// Key of the data source.
+	Key string `json:"key"`
+
+	// EnvName is the name of the environment variable. Defaults to the key.
+	EnvName string `json:"envName,omitempty"`
+
+	// Optional indicates whether the key may be missing from the data source.
+	Optional bool `json:"optional,omitempty"`
 }
 
 // A WorkloadReference refers to an OAM workload resource.
@@ -99,12 +274,119 @@ type WorkloadReference struct {
 	Name string `json:"name"`
 }
 
+// ServiceBindingStatus defines the observed state of ServiceBinding
 type ServiceBindingStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// ObservedGeneration is the generation of the ServiceBinding last applied to the workload.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions of the ServiceBinding: SourceResolved, Injected and Ready.
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// Bindings reports the resolved source and targets of each binding.
+	Bindings []BindingStatus `json:"bindings,omitempty"`
+
+	// InjectedWorkload is the workload the ServiceBinding was last injected into, whose bindings
+	// are removed when the WorkloadRef changes.
+	InjectedWorkload *WorkloadReference `json:"injectedWorkload,omitempty"`
+}
+
+// BindingStatus is the observed state of one of the bindings.
+type BindingStatus struct {
+	// Index of the binding in spec.bindings.
+	Index int `json:"index"`
+
+	// SecretName is the resolved name of the secret, including one read from NameFromField.
+	SecretName string `json:"secretName,omitempty"`
+
+	// ConfigMapName is the resolved name of the configmap, including one read from NameFromField.
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// ServiceName is the resolved name of the service, including one read from NameFromField.
+	ServiceName string `json:"serviceName,omitempty"`
+
+	// PVCName is the name of the PVC.
+	PVCName string `json:"pvcName,omitempty"`
+
+	// Workloads that received the binding.
+	Workloads []BoundWorkload `json:"workloads,omitempty"`
+}
+
+// BoundWorkload is a workload and the containers a binding has been injected into.
+type BoundWorkload struct {
+	WorkloadReference `json:",inline"`
+
+	// Containers the binding has been injected into.
+	Containers []string `json:"containers,omitempty"`
+}
+
+// ConditionType is a type of condition of a ServiceBinding.
+type ConditionType string
+
+const (
+	// ConditionSourceResolved indicates whether the data sources of all bindings have been resolved.
+	ConditionSourceResolved ConditionType = "SourceResolved"
+
+	// ConditionInjected indicates whether all bindings have been injected into the workload.
+	ConditionInjected ConditionType = "Injected"
+
+	// ConditionReady indicates whether the ServiceBinding is fully applied to the workload.
+	ConditionReady ConditionType = "Ready"
+)
+
+// Condition describes an aspect of the state of a ServiceBinding. It has the
+// same fields as metav1.Condition of newer Kubernetes releases.
+type Condition struct {
+	// Type of the condition.
+	Type ConditionType `json:"type"`
+
+	// Status of the condition, one of True, False, Unknown.
+	Status metav1.ConditionStatus `json:"status"`
+
+	// ObservedGeneration is the generation of the ServiceBinding the condition was set upon.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastTransitionTime is the last time the condition changed its status.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// Reason is a CamelCase reason for the condition's last transition.
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human readable message about the transition.
+	Message string `json:"message,omitempty"`
+}
+
+// GetCondition returns the condition of the given type, or nil if not set.
+func (s *ServiceBindingStatus) GetCondition(t ConditionType) *Condition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds or updates the condition of the same type, keeping its
+// last transition time unless the status changes.
+func (s *ServiceBindingStatus) SetCondition(c Condition) {
+	if c.LastTransitionTime.IsZero() {
+		c.LastTransitionTime = metav1.Now()
+	}
+	existing := s.GetCondition(c.Type)
+	if existing == nil {
+		s.Conditions = append(s.Conditions, c)
+		return
+	}
+	if existing.Status == c.Status {
+		c.LastTransitionTime = existing.LastTransitionTime
+	}
+	*existing = c
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ServiceBinding is the Schema for the servicebindings API
 type ServiceBinding struct {
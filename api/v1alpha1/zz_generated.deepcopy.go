@@ -20,6 +20,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -27,7 +28,7 @@ import (
 func (in *Binding) DeepCopyInto(out *Binding) {
 	*out = *in
 	in.From.DeepCopyInto(&out.From)
-	out.To = in.To
+	in.To.DeepCopyInto(&out.To)
 	if in.ContainerSelector != nil {
 		in, out := &in.ContainerSelector, &out.ContainerSelector
 		*out = new(ContainerSelector)
@@ -45,6 +46,100 @@ func (in *Binding) DeepCopy() *Binding {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BindingRootTarget) DeepCopyInto(out *BindingRootTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BindingRootTarget.
+func (in *BindingRootTarget) DeepCopy() *BindingRootTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(BindingRootTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BindingStatus) DeepCopyInto(out *BindingStatus) {
+	*out = *in
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]BoundWorkload, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BindingStatus.
+func (in *BindingStatus) DeepCopy() *BindingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BindingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BoundWorkload) DeepCopyInto(out *BoundWorkload) {
+	*out = *in
+	out.WorkloadReference = in.WorkloadReference
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BoundWorkload.
+func (in *BoundWorkload) DeepCopy() *BoundWorkload {
+	if in == nil {
+		return nil
+	}
+	out := new(BoundWorkload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapSource) DeepCopyInto(out *ConfigMapSource) {
+	*out = *in
+	if in.NameFromField != nil {
+		in, out := &in.NameFromField, &out.NameFromField
+		*out = new(SecretNameFromField)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapSource.
+func (in *ConfigMapSource) DeepCopy() *ConfigMapSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerSelector) DeepCopyInto(out *ContainerSelector) {
 	*out = *in
@@ -73,11 +168,36 @@ func (in *DataSource) DeepCopyInto(out *DataSource) {
 		*out = new(SecretSource)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ConfigMapSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ServiceSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Template != nil {
+		in, out := &in.Template, &out.Template
+		*out = new(TemplateSource)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Volume != nil {
 		in, out := &in.Volume, &out.Volume
 		*out = new(VolumeSource)
 		**out = **in
 	}
+	if in.DownwardAPI != nil {
+		in, out := &in.DownwardAPI, &out.DownwardAPI
+		*out = new(DownwardAPISource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccountToken != nil {
+		in, out := &in.ServiceAccountToken, &out.ServiceAccountToken
+		*out = new(ServiceAccountTokenSource)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataSource.
@@ -93,6 +213,28 @@ func (in *DataSource) DeepCopy() *DataSource {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataTarget) DeepCopyInto(out *DataTarget) {
 	*out = *in
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]v1.KeyToPath, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DefaultMode != nil {
+		in, out := &in.DefaultMode, &out.DefaultMode
+		*out = new(int32)
+		**out = **in
+	}
+	if in.EnvMappings != nil {
+		in, out := &in.EnvMappings, &out.EnvMappings
+		*out = make([]EnvMapping, len(*in))
+		copy(*out, *in)
+	}
+	if in.BindingRoot != nil {
+		in, out := &in.BindingRoot, &out.BindingRoot
+		*out = new(BindingRootTarget)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataTarget.
@@ -105,6 +247,116 @@ func (in *DataTarget) DeepCopy() *DataTarget {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DownwardAPISource) DeepCopyInto(out *DownwardAPISource) {
+	*out = *in
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]v1.DownwardAPIVolumeFile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownwardAPISource.
+func (in *DownwardAPISource) DeepCopy() *DownwardAPISource {
+	if in == nil {
+		return nil
+	}
+	out := new(DownwardAPISource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvMapping) DeepCopyInto(out *EnvMapping) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvMapping.
+func (in *EnvMapping) DeepCopy() *EnvMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InjectionTarget) DeepCopyInto(out *InjectionTarget) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InjectionTarget.
+func (in *InjectionTarget) DeepCopy() *InjectionTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(InjectionTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InjectionTarget) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InjectionTargetList) DeepCopyInto(out *InjectionTargetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]InjectionTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InjectionTargetList.
+func (in *InjectionTargetList) DeepCopy() *InjectionTargetList {
+	if in == nil {
+		return nil
+	}
+	out := new(InjectionTargetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *InjectionTargetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InjectionTargetSpec) DeepCopyInto(out *InjectionTargetSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InjectionTargetSpec.
+func (in *InjectionTargetSpec) DeepCopy() *InjectionTargetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InjectionTargetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretNameFromField) DeepCopyInto(out *SecretNameFromField) {
 	*out = *in
@@ -140,13 +392,33 @@ func (in *SecretSource) DeepCopy() *SecretSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccountTokenSource) DeepCopyInto(out *ServiceAccountTokenSource) {
+	*out = *in
+	if in.ExpirationSeconds != nil {
+		in, out := &in.ExpirationSeconds, &out.ExpirationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccountTokenSource.
+func (in *ServiceAccountTokenSource) DeepCopy() *ServiceAccountTokenSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccountTokenSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceBinding) DeepCopyInto(out *ServiceBinding) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceBinding.
@@ -229,6 +501,25 @@ func (in *ServiceBindingSpec) DeepCopy() *ServiceBindingSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceBindingStatus) DeepCopyInto(out *ServiceBindingStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Bindings != nil {
+		in, out := &in.Bindings, &out.Bindings
+		*out = make([]BindingStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InjectedWorkload != nil {
+		in, out := &in.InjectedWorkload, &out.InjectedWorkload
+		*out = new(WorkloadReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceBindingStatus.
@@ -241,6 +532,85 @@ func (in *ServiceBindingStatus) DeepCopy() *ServiceBindingStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceSource) DeepCopyInto(out *ServiceSource) {
+	*out = *in
+	if in.NameFromField != nil {
+		in, out := &in.NameFromField, &out.NameFromField
+		*out = new(SecretNameFromField)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceSource.
+func (in *ServiceSource) DeepCopy() *ServiceSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateInput) DeepCopyInto(out *TemplateInput) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(SecretSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ConfigMapSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Field != nil {
+		in, out := &in.Field, &out.Field
+		*out = new(SecretNameFromField)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateInput.
+func (in *TemplateInput) DeepCopy() *TemplateInput {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateInput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateSource) DeepCopyInto(out *TemplateSource) {
+	*out = *in
+	if in.Inputs != nil {
+		in, out := &in.Inputs, &out.Inputs
+		*out = make([]TemplateInput, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateSource.
+func (in *TemplateSource) DeepCopy() *TemplateSource {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeSource) DeepCopyInto(out *VolumeSource) {
 	*out = *in
@@ -0,0 +1,70 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// workloadVerbs are the verbs the injector needs on the workloads it injects.
+var workloadVerbs = []string{"get", "list", "patch"}
+
+// CheckWorkloadAccess returns an error unless the injector is allowed to get,
+// list and patch the workloads of the kind in every namespace, so that the
+// workloads of the kinds added by InjectionTargets are not left uninjected
+// for lack of RBAC rules.
+func CheckWorkloadAccess(ctx context.Context, c client.Client, mapper meta.RESTMapper, gvk schema.GroupVersionKind) error {
+	return checkAccess(ctx, c, mapper, gvk, workloadVerbs...)
+}
+
+// checkAccess returns an error unless the injector is allowed the verbs on the
+// objects of the kind in every namespace.
+func checkAccess(ctx context.Context, c client.Client, mapper meta.RESTMapper, gvk schema.GroupVersionKind, verbs ...string) error {
+	m, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return err
+	}
+	var denied []string
+	for _, verb := range verbs {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Verb:     verb,
+					Group:    m.Resource.Group,
+					Version:  m.Resource.Version,
+					Resource: m.Resource.Resource,
+				},
+			},
+		}
+		if err := c.Create(ctx, review); err != nil {
+			return fmt.Errorf("review access to %s err: %w", m.Resource.GroupResource(), err)
+		}
+		if !review.Status.Allowed {
+			denied = append(denied, verb)
+		}
+	}
+	if len(denied) != 0 {
+		return fmt.Errorf("%s of %s not allowed", strings.Join(denied, ", "), m.Resource.GroupResource())
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// admissionReview is an AdmissionReview received in either admission.k8s.io/v1
+// or admission.k8s.io/v1beta1. The request is always handled as v1beta1, which
+// has the same fields as v1, and the response is written back in the version
+// the API server sent.
+type admissionReview struct {
+	metav1.TypeMeta
+
+	Request *admissionv1beta1.AdmissionRequest
+}
+
+func decodeAdmissionReview(body []byte) (*admissionReview, error) {
+	review := &admissionReview{}
+	if err := json.Unmarshal(body, &review.TypeMeta); err != nil {
+		return nil, err
+	}
+
+	switch review.APIVersion {
+	case admissionv1.SchemeGroupVersion.String():
+		v1Review := &admissionv1.AdmissionReview{}
+		if err := json.Unmarshal(body, v1Review); err != nil {
+			return nil, err
+		}
+		if v1Review.Request != nil {
+			review.Request = &admissionv1beta1.AdmissionRequest{}
+			if err := convertAdmission(v1Review.Request, review.Request); err != nil {
+				return nil, err
+			}
+		}
+	case admissionv1beta1.SchemeGroupVersion.String(), "":
+		v1beta1Review := &admissionv1beta1.AdmissionReview{}
+		if err := json.Unmarshal(body, v1beta1Review); err != nil {
+			return nil, err
+		}
+		review.Request = v1beta1Review.Request
+	default:
+		return nil, fmt.Errorf("unsupported AdmissionReview version: %s", review.APIVersion)
+	}
+
+	if review.Request == nil {
+		return nil, fmt.Errorf("AdmissionReview has no request")
+	}
+	return review, nil
+}
+
+// marshal encodes the given response, as built by newAdmissionResponse,
+// into an AdmissionReview of the request's version.
+func (ar *admissionReview) marshal(resp interface{}) ([]byte, error) {
+	switch resp := resp.(type) {
+	case *admissionv1.AdmissionResponse:
+		return json.Marshal(&admissionv1.AdmissionReview{
+			TypeMeta: ar.TypeMeta,
+			Response: resp,
+		})
+	case *admissionv1beta1.AdmissionResponse:
+		tm := ar.TypeMeta
+		if len(tm.APIVersion) == 0 {
+			tm = metav1.TypeMeta{
+				APIVersion: admissionv1beta1.SchemeGroupVersion.String(),
+				Kind:       "AdmissionReview",
+			}
+		}
+		return json.Marshal(&admissionv1beta1.AdmissionReview{
+			TypeMeta: tm,
+			Response: resp,
+		})
+	default:
+		return nil, fmt.Errorf("unknown AdmissionResponse type %T", resp)
+	}
+}
+
+// newAdmissionResponse builds the response to the review, in the
+// AdmissionResponse flavour matching the review's version. If err is not nil
+// the request is denied with a status describing err, otherwise it is allowed
+// with the result's patches.
+func newAdmissionResponse(review *admissionReview, result *admissionResult, err error) (interface{}, error) {
+	resp := &admissionv1beta1.AdmissionResponse{
+		UID: review.Request.UID,
+	}
+
+	if err != nil {
+		resp.Allowed = false
+		resp.Result = newDenialStatus(err)
+	} else {
+		p, err := json.Marshal(result.Patches)
+		if err != nil {
+			return nil, err
+		}
+		pT := admissionv1beta1.PatchTypeJSONPatch
+		resp.Allowed = true
+		resp.PatchType = &pT
+		resp.Patch = p
+		resp.Result = &metav1.Status{
+			Status: metav1.StatusSuccess,
+		}
+
+		annotations := map[string]string{}
+		if len(result.ServiceBindings) > 0 {
+			annotations["servicebindings"] = strings.Join(result.ServiceBindings, ",")
+		}
+		if len(result.Warnings) > 0 {
+			annotations["warnings"] = strings.Join(result.Warnings, "; ")
+			resp.Result.Message = annotations["warnings"]
+		}
+		if len(annotations) > 0 {
+			resp.AuditAnnotations = annotations
+		}
+	}
+
+	if review.APIVersion == admissionv1.SchemeGroupVersion.String() {
+		v1Resp := &admissionv1.AdmissionResponse{}
+		if err := convertAdmission(resp, v1Resp); err != nil {
+			return nil, err
+		}
+		return v1Resp, nil
+	}
+	return resp, nil
+}
+
+// newStatusError wraps err with the reason and code an admission request is
+// denied with because of it.
+func newStatusError(reason metav1.StatusReason, code int32, err error) error {
+	return &statusError{
+		reason: reason,
+		code:   code,
+		err:    err,
+	}
+}
+
+type statusError struct {
+	reason metav1.StatusReason
+	code   int32
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// newDenialStatus describes err as the status of a denied admission request.
+// The reason and code are taken from a wrapped statusError or API error, and
+// default to an internal error.
+func newDenialStatus(err error) *metav1.Status {
+	status := &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Message: err.Error(),
+		Reason:  metav1.StatusReasonInternalError,
+		Code:    http.StatusInternalServerError,
+	}
+
+	var se *statusError
+	var apiStatus apierrors.APIStatus
+	switch {
+	case errors.As(err, &se):
+		status.Reason = se.reason
+		status.Code = se.code
+	case errors.As(err, &apiStatus):
+		status.Reason = apiStatus.Status().Reason
+		status.Code = apiStatus.Status().Code
+	}
+	return status
+}
+
+// convertAdmission copies between the v1 and v1beta1 variants of admission
+// types, which share the same JSON representation.
+func convertAdmission(in, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
@@ -0,0 +1,105 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("AdmissionReview", func() {
+	newRequest := func(apiVersion string) []byte {
+		b, err := json.Marshal(map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       "AdmissionReview",
+			"request": map[string]interface{}{
+				"uid":       "test-uid",
+				"kind":      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+				"name":      "test-deploy",
+				"namespace": "default",
+				"operation": "CREATE",
+			},
+		})
+		Expect(err).To(BeNil())
+		return b
+	}
+
+	It("should answer v1 review in v1", func() {
+		review, err := decodeAdmissionReview(newRequest("admission.k8s.io/v1"))
+		Expect(err).To(BeNil())
+		Expect(review.Request.Name).To(Equal("test-deploy"))
+		Expect(review.Request.Kind.Kind).To(Equal("Deployment"))
+
+		resp, err := newAdmissionResponse(review, &admissionResult{}, nil)
+		Expect(err).To(BeNil())
+		b, err := review.marshal(resp)
+		Expect(err).To(BeNil())
+		out := &admissionv1.AdmissionReview{}
+		Expect(json.Unmarshal(b, out)).To(Succeed())
+		Expect(out.APIVersion).To(Equal("admission.k8s.io/v1"))
+		Expect(out.Kind).To(Equal("AdmissionReview"))
+		Expect(string(out.Response.UID)).To(Equal("test-uid"))
+		Expect(out.Response.Allowed).To(BeTrue())
+		Expect(*out.Response.PatchType).To(Equal(admissionv1.PatchTypeJSONPatch))
+	})
+
+	It("should answer v1beta1 review in v1beta1", func() {
+		review, err := decodeAdmissionReview(newRequest("admission.k8s.io/v1beta1"))
+		Expect(err).To(BeNil())
+
+		resp, err := newAdmissionResponse(review, &admissionResult{}, nil)
+		Expect(err).To(BeNil())
+		b, err := review.marshal(resp)
+		Expect(err).To(BeNil())
+		out := &admissionv1beta1.AdmissionReview{}
+		Expect(json.Unmarshal(b, out)).To(Succeed())
+		Expect(out.APIVersion).To(Equal("admission.k8s.io/v1beta1"))
+		Expect(string(out.Response.UID)).To(Equal("test-uid"))
+		Expect(out.Response.Allowed).To(BeTrue())
+	})
+
+	It("should deny with the status of the injection error", func() {
+		review, err := decodeAdmissionReview(newRequest("admission.k8s.io/v1"))
+		Expect(err).To(BeNil())
+
+		injectErr := fmt.Errorf("servicebinding test bindings[0]: %w",
+			newStatusError(metav1.StatusReasonNotFound, http.StatusNotFound, fmt.Errorf("fieldPath .status.secret not found")))
+		resp, err := newAdmissionResponse(review, nil, injectErr)
+		Expect(err).To(BeNil())
+		b, err := review.marshal(resp)
+		Expect(err).To(BeNil())
+		out := &admissionv1.AdmissionReview{}
+		Expect(json.Unmarshal(b, out)).To(Succeed())
+		Expect(out.Response.Allowed).To(BeFalse())
+		Expect(out.Response.Patch).To(BeNil())
+		Expect(out.Response.Result.Status).To(Equal(metav1.StatusFailure))
+		Expect(out.Response.Result.Reason).To(Equal(metav1.StatusReasonNotFound))
+		Expect(out.Response.Result.Code).To(Equal(int32(http.StatusNotFound)))
+		Expect(out.Response.Result.Message).To(Equal("servicebinding test bindings[0]: fieldPath .status.secret not found"))
+	})
+
+	It("should reject unknown review version", func() {
+		_, err := decodeAdmissionReview(newRequest("admission.k8s.io/v2"))
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,94 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// resolveBindingRoot copies the data of the resolved source of the binding at
+// the index into the secret mounted under the binding root, along with the
+// type and provider of the binding, and returns the values injecting it.
+func (r *ServiceBindingReconciler) resolveBindingRoot(req *admissionv1beta1.AdmissionRequest, sb *corev1alpha1.ServiceBinding, index int, values map[string]interface{}) (map[string]interface{}, error) {
+	t := sb.Spec.Bindings[index].To.BindingRoot
+	secretName := managedName(sb, "binding", index)
+	rootValues := map[string]interface{}{
+		"secret-name": secretName,
+	}
+	if checksum, ok := values["secret-checksum"]; ok {
+		rootValues["secret-checksum"] = checksum
+	}
+	if isDryRun(req) {
+		return rootValues, nil
+	}
+
+	data, err := r.sourceData(req.Namespace, values)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Type) != 0 {
+		data["type"] = []byte(t.Type)
+	}
+	if len(t.Provider) != 0 {
+		data["provider"] = []byte(t.Provider)
+	}
+	if _, ok := data["type"]; !ok {
+		return nil, newStatusError(metav1.StatusReasonInvalid, http.StatusUnprocessableEntity, errors.New("bindingRoot type is required as the source has no type key"))
+	}
+	if err := r.ensureManagedSecret(sb, secretName, data); err != nil {
+		return nil, err
+	}
+	return rootValues, nil
+}
+
+// sourceData returns a copy of the data of the resolved secret or configmap.
+func (r *ServiceBindingReconciler) sourceData(namespace string, values map[string]interface{}) (map[string][]byte, error) {
+	ctx := context.Background()
+	data := map[string][]byte{}
+	if name, _ := values["secret-name"].(string); len(name) != 0 {
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+			return nil, fmt.Errorf("get secret %s: %w", name, err)
+		}
+		for k, v := range secret.Data {
+			data[k] = v
+		}
+		return data, nil
+	}
+	if name, _ := values["configmap-name"].(string); len(name) != 0 {
+		cm := &corev1.ConfigMap{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cm); err != nil {
+			return nil, fmt.Errorf("get configmap %s: %w", name, err)
+		}
+		for k, v := range cm.BinaryData {
+			data[k] = v
+		}
+		for k, v := range cm.Data {
+			data[k] = []byte(v)
+		}
+		return data, nil
+	}
+	return nil, newStatusError(metav1.StatusReasonInvalid, http.StatusUnprocessableEntity, errors.New("bindingRoot requires a secret, configMap, service or template source"))
+}
@@ -0,0 +1,132 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reasonSecretNotFound is the reason of the events about binding secrets
+// that do not exist yet.
+const reasonSecretNotFound = "SecretNotFound"
+
+// reasonUninjectionFailed is the reason of the events about workloads the
+// bindings of a deleted ServiceBinding could not be removed from.
+const reasonUninjectionFailed = "UninjectionFailed"
+
+// injection describes a binding newly injected into the workload.
+type injection struct {
+	// Source is the kind and name of the binding source.
+	Source string
+
+	// Containers are the names of the containers injected.
+	Containers []string
+}
+
+// recordEvents records the outcome of injecting each ServiceBinding attempted
+// as events on the ServiceBinding, and on the workload if given.
+func (r *ServiceBindingReconciler) recordEvents(workload runtime.Object, result *admissionResult) {
+	for _, res := range result.Results {
+		r.recordServiceBindingEvents(workload, res)
+	}
+}
+
+func (r *ServiceBindingReconciler) recordServiceBindingEvents(workload runtime.Object, res *serviceBindingResult) {
+	if r.Recorder == nil {
+		return
+	}
+	sb := res.ServiceBinding
+	for _, in := range res.Injected {
+		msg := fmt.Sprintf("Injected %s into containers %s of %s %s", in.Source, strings.Join(in.Containers, ","), sb.Spec.WorkloadRef.Kind, sb.Spec.WorkloadRef.Name)
+		r.Recorder.Event(sb, corev1.EventTypeNormal, reasonInjected, msg)
+		if workload != nil {
+			msg := fmt.Sprintf("ServiceBinding %s injected %s into containers %s", sb.Name, in.Source, strings.Join(in.Containers, ","))
+			r.Recorder.Event(workload, corev1.EventTypeNormal, reasonInjected, msg)
+		}
+	}
+	for _, name := range res.MissingSecrets {
+		r.Recorder.Eventf(sb, corev1.EventTypeWarning, reasonSecretNotFound, "Secret %s not found", name)
+	}
+	for _, err := range res.ResolveErrs {
+		r.Recorder.Event(sb, corev1.EventTypeWarning, reasonSourceNotResolved, err.Error())
+	}
+	for _, err := range res.DeferredErrs {
+		r.Recorder.Event(sb, corev1.EventTypeNormal, reasonWaitingForSource, err.Error())
+	}
+	for _, err := range res.WatchErrs {
+		r.Recorder.Event(sb, corev1.EventTypeWarning, reasonSourceNotWatched, err.Error())
+	}
+	if res.Unsupported {
+		r.Recorder.Eventf(sb, corev1.EventTypeWarning, reasonUnsupportedWorkload, "No target injector supports %s %s", sb.Spec.WorkloadRef.APIVersion, sb.Spec.WorkloadRef.Kind)
+	}
+	for _, err := range res.InjectErrs {
+		r.Recorder.Event(sb, corev1.EventTypeWarning, reasonInjectionFailed, err.Error())
+		if workload != nil {
+			r.Recorder.Event(workload, corev1.EventTypeWarning, reasonInjectionFailed, err.Error())
+		}
+	}
+}
+
+// requestWorkload returns the workload of the admission request as the
+// involved object of events, or nil if it cannot be decoded.
+func requestWorkload(req *admissionv1beta1.AdmissionRequest) runtime.Object {
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON(req.Object.Raw); err != nil {
+		return nil
+	}
+	if len(u.GetNamespace()) == 0 {
+		u.SetNamespace(req.Namespace)
+	}
+	if len(u.GetName()) == 0 {
+		u.SetName(req.Name)
+	}
+	return u
+}
+
+// secretExists reports whether the secret exists. Errors other than not
+// found are reported as existing, as the secret is only checked to warn.
+func (r *ServiceBindingReconciler) secretExists(namespace, name string) bool {
+	err := r.Client.Get(context.Background(), client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}, &corev1.Secret{})
+	return !apierrors.IsNotFound(err)
+}
+
+// bindingSource describes the resolved source of the binding.
+func bindingSource(bs corev1alpha1.BindingStatus) string {
+	switch {
+	case len(bs.ServiceName) != 0:
+		return "service " + bs.ServiceName
+	case len(bs.SecretName) != 0:
+		return "secret " + bs.SecretName
+	case len(bs.ConfigMapName) != 0:
+		return "configmap " + bs.ConfigMapName
+	case len(bs.PVCName) != 0:
+		return "persistentvolumeclaim " + bs.PVCName
+	}
+	return "binding"
+}
@@ -0,0 +1,157 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// mirrorAnnotation lists the namespaces, separated by commas, a secret may be
+// mirrored into by ServiceBindings, or "*" for any namespace.
+const mirrorAnnotation = "secret.core.oam.dev/mirror-namespaces"
+
+// resolveMirror resolves the secret of another namespace and mirrors it into
+// the namespace of the ServiceBinding, returning the values injecting the
+// mirror. The mirror is deleted if the secret is gone or does not allow it
+// anymore.
+func (r *ServiceBindingReconciler) resolveMirror(req *admissionv1beta1.AdmissionRequest, sb *corev1alpha1.ServiceBinding, index int, s *corev1alpha1.SecretSource) (map[string]interface{}, error) {
+	mirrorName := managedName(sb, "secret", index)
+	secret, revoke, err := r.getMirroredSecret(sb.Namespace, s)
+	if isDryRun(req) {
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"secret-name": mirrorName,
+		}, nil
+	}
+	if err != nil {
+		if revoke {
+			if err := r.deleteManagedSecret(sb, mirrorName); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	if err := r.ensureManagedSecret(sb, mirrorName, secret.Data); err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{
+		"secret-name": mirrorName,
+	}
+	if s.RolloutOnChange {
+		values["secret-checksum"] = dataChecksum(secret.Data)
+	}
+	return values, nil
+}
+
+// getMirroredSecret gets the secret of another namespace the source refers
+// to, failing unless it allows mirroring into the namespace. The name read
+// from an object field is resolved in the namespace, and a missing secret is
+// reported as one denying the mirror, so that the errors disclose neither the
+// secrets of the other namespace nor the names read. revoke tells whether a
+// mirror of the secret is to be deleted.
+func (r *ServiceBindingReconciler) getMirroredSecret(namespace string, s *corev1alpha1.SecretSource) (secret *corev1.Secret, revoke bool, err error) {
+	name, err := r.resolveSourceName(namespace, s.Name, s.NameFromField)
+	if err != nil {
+		return nil, false, err
+	}
+	ref := s.Namespace + "/" + name
+	if s.NameFromField != nil {
+		ref = fmt.Sprintf("named by %s %s in namespace %s", s.NameFromField.Kind, s.NameFromField.Name, s.Namespace)
+	}
+	denied := newStatusError(metav1.StatusReasonForbidden, http.StatusForbidden, fmt.Errorf("secret %s does not exist or does not allow mirroring into namespace %s", ref, namespace))
+
+	secret = &corev1.Secret{}
+	err = r.Client.Get(context.Background(), client.ObjectKey{Namespace: s.Namespace, Name: name}, secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil, true, denied
+	case apierrors.IsForbidden(err):
+		return nil, false, denied
+	case err != nil && s.NameFromField != nil:
+		// the errors of the API server name the secret
+		return nil, false, fmt.Errorf("get secret %s failed", ref)
+	case err != nil:
+		return nil, false, fmt.Errorf("get secret %s: %w", ref, err)
+	case !mirrorAllowed(secret, namespace):
+		return nil, true, denied
+	}
+	return secret, false, nil
+}
+
+// mirrorAllowed tells whether the annotation of the secret allows mirroring it
+// into the namespace.
+func mirrorAllowed(secret *corev1.Secret, namespace string) bool {
+	for _, ns := range strings.Split(secret.Annotations[mirrorAnnotation], ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "*" || ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// mirrorRefers tells whether the binding, or an input of its template, may
+// mirror the secret of the given namespace and name. Sources reading the name
+// from an object field may mirror any secret of their namespace.
+func mirrorRefers(b corev1alpha1.Binding, namespace, name string) bool {
+	refers := func(s *corev1alpha1.SecretSource) bool {
+		return s != nil && s.Namespace == namespace && (s.NameFromField != nil || s.Name == name)
+	}
+	if refers(b.From.Secret) {
+		return true
+	}
+	if b.From.Template != nil {
+		for _, in := range b.From.Template.Inputs {
+			if refers(in.Secret) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deleteManagedSecret deletes the secret if the ServiceBinding manages it.
+func (r *ServiceBindingReconciler) deleteManagedSecret(sb *corev1alpha1.ServiceBinding, name string) error {
+	ctx := context.Background()
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: sb.Namespace, Name: name}, secret)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get secret %s: %w", name, err)
+	}
+	if !metav1.IsControlledBy(secret, sb) {
+		return nil
+	}
+	if err := r.Client.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("delete secret %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// secretChecksum returns the checksum of the secret data, or an empty string
+// if the secret does not exist yet.
+func (r *ServiceBindingReconciler) secretChecksum(namespace, name string) (string, error) {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(context.Background(), client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}, secret)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get secret %s: %w", name, err)
+	}
+	return dataChecksum(secret.Data), nil
+}
+
+// dataChecksum returns the SHA-256 checksum of the data, in key order.
+func dataChecksum(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write(data[k])
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// secretServiceBindings maps a secret to the ServiceBindings rolling their
+// workload, mirroring it, copying it under the binding root or rendering
+// templates when its data changes.
+func (r *ServiceBindingReconciler) secretServiceBindings(o handler.MapObject) []reconcile.Request {
+	namespace, name := o.Meta.GetNamespace(), o.Meta.GetName()
+	sbl := &corev1alpha1.ServiceBindingList{}
+	// secrets are mirrored into the namespaces of other ServiceBindings
+	if err := r.Client.List(context.Background(), sbl); err != nil {
+		r.Log.Error(err, "list servicebindings", "secret", namespace+"/"+name)
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for i := range sbl.Items {
+		sb := &sbl.Items[i]
+		for j, b := range sb.Spec.Bindings {
+			var refers bool
+			if sb.Namespace == namespace {
+				bound := b.From.Secret != nil && boundSecretName(sb, j) == name
+				refers = bound && (b.From.Secret.RolloutOnChange || b.To.BindingRoot != nil) || templateInputRefers(b, true, name)
+			} else {
+				refers = mirrorRefers(b, namespace, name)
+			}
+			if refers {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: sb.Namespace,
+					Name:      sb.Name,
+				}})
+				break
+			}
+		}
+	}
+	return reqs
+}
+
+// boundSecretName returns the name of the secret of the binding at the index,
+// as resolved in the ServiceBinding status if read from an object field.
+func boundSecretName(sb *corev1alpha1.ServiceBinding, index int) string {
+	for _, bs := range sb.Status.Bindings {
+		if bs.Index == index && len(bs.SecretName) != 0 {
+			return bs.SecretName
+		}
+	}
+	return sb.Spec.Bindings[index].From.Secret.Name
+}
@@ -0,0 +1,152 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// managedLabel labels the objects managed by a ServiceBinding with its name.
+const managedLabel = "core.oam.dev/servicebinding"
+
+// managedName returns the name of the object the ServiceBinding manages for
+// the binding at the index, from a source of the given kind.
+func managedName(sb *corev1alpha1.ServiceBinding, kind string, index int) string {
+	return fmt.Sprintf("%s-%s-%d", sb.Name, kind, index)
+}
+
+// resolveService returns the name of the service and the data describing its
+// endpoint.
+func (r *ServiceBindingReconciler) resolveService(namespace string, s *corev1alpha1.ServiceSource) (string, map[string]string, error) {
+	name, err := r.resolveSourceName(namespace, s.Name, s.NameFromField)
+	if err != nil {
+		return "", nil, err
+	}
+	svc := &corev1.Service{}
+	err = r.Client.Get(context.Background(), client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}, svc)
+	if err != nil {
+		return "", nil, fmt.Errorf("get service %s: %w", name, err)
+	}
+	return name, serviceData(svc, s.Scheme), nil
+}
+
+// serviceData returns the cluster DNS name, ports and URL of the service.
+func serviceData(svc *corev1.Service, scheme string) map[string]string {
+	host := fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace)
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		host = svc.Spec.ExternalName
+	}
+	data := map[string]string{
+		"host": host,
+	}
+	for i, p := range svc.Spec.Ports {
+		port := strconv.Itoa(int(p.Port))
+		if i == 0 {
+			data["port"] = port
+		}
+		if len(p.Name) != 0 {
+			data["port_"+strings.Replace(p.Name, "-", "_", -1)] = port
+		}
+	}
+	if len(scheme) != 0 {
+		data["scheme"] = scheme
+		url := scheme + "://" + host
+		if port, ok := data["port"]; ok {
+			url += ":" + port
+		}
+		data["url"] = url
+	}
+	return data
+}
+
+// ensureManagedConfigMap creates or updates the configmap with the data,
+// owned by the ServiceBinding so that it is deleted along with it. An existing
+// configmap the ServiceBinding does not control is left alone.
+func (r *ServiceBindingReconciler) ensureManagedConfigMap(sb *corev1alpha1.ServiceBinding, name string, data map[string]string) error {
+	cm := &corev1.ConfigMap{}
+	cm.Namespace = sb.Namespace
+	cm.Name = name
+	_, err := controllerutil.CreateOrUpdate(context.Background(), r.Client, cm, func() error {
+		if len(cm.ResourceVersion) != 0 && !metav1.IsControlledBy(cm, sb) {
+			return newStatusError(metav1.StatusReasonConflict, http.StatusConflict, fmt.Errorf("configmap %s is not managed by servicebinding %s", name, sb.Name))
+		}
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		cm.Labels[managedLabel] = sb.Name
+		cm.Data = data
+		return controllerutil.SetControllerReference(sb, cm, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("update configmap %s: %w", name, err)
+	}
+	return nil
+}
+
+// serviceServiceBindings maps a service to the ServiceBindings injecting its
+// endpoint, so that their managed data follows changes of the service.
+func (r *ServiceBindingReconciler) serviceServiceBindings(o handler.MapObject) []reconcile.Request {
+	sbl := &corev1alpha1.ServiceBindingList{}
+	if err := r.Client.List(context.Background(), sbl, client.InNamespace(o.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "list servicebindings", "service", o.Meta.GetNamespace()+"/"+o.Meta.GetName())
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for i := range sbl.Items {
+		sb := &sbl.Items[i]
+		for j, b := range sb.Spec.Bindings {
+			if b.From.Service == nil {
+				continue
+			}
+			if boundServiceName(sb, j) == o.Meta.GetName() {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: sb.Namespace,
+					Name:      sb.Name,
+				}})
+				break
+			}
+		}
+	}
+	return reqs
+}
+
+// boundServiceName returns the name of the service of the binding at the
+// index, as resolved in the ServiceBinding status if read from an object field.
+func boundServiceName(sb *corev1alpha1.ServiceBinding, index int) string {
+	for _, bs := range sb.Status.Bindings {
+		if bs.Index == index && len(bs.ServiceName) != 0 {
+			return bs.ServiceName
+		}
+	}
+	return sb.Spec.Bindings[index].From.Service.Name
+}
@@ -18,17 +18,25 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"path"
-	"strings"
+	"sort"
+	"sync"
 	"time"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/go-logr/logr"
 	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	"github.com/oam-dev/trait-injector/pkg/fieldpath"
+	"github.com/oam-dev/trait-injector/pkg/injector"
 	"github.com/oam-dev/trait-injector/pkg/plugin"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -36,33 +44,249 @@ import (
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
+// finalizerName is the finalizer removing the injected bindings from the
+// workload before a ServiceBinding is deleted.
+const finalizerName = "finalizer.servicebinding.core.oam.dev"
+
 // ServiceBindingReconciler reconciles a ServiceBinding object
 type ServiceBindingReconciler struct {
 	Client   client.Client
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+
+	// Injectors are the target injectors of the workloads.
+	Injectors *plugin.Registry
+
+	controller controller.Controller
+	mapper     meta.RESTMapper
+	mu         sync.Mutex
+	watched    map[schema.GroupVersionKind]bool
 }
 
+// +kubebuilder:rbac:groups=core.oam.dev,resources=injectiontargets,verbs=get;list
 // +kubebuilder:rbac:groups=core.oam.dev,resources=servicebindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core.oam.dev,resources=servicebindings/status,verbs=get;update;patch
-
+// +kubebuilder:rbac:groups=apps,resources=daemonsets;deployments;replicasets;statefulsets,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=batch,resources=cronjobs;jobs,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile injects a ServiceBinding into its workload if the workload already
+// exists, so that bindings created or changed after the workload was admitted
+// take effect without recreating it.
 func (r *ServiceBindingReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	_ = context.Background()
-	_ = r.Log.WithValues("servicebinding", req.NamespacedName)
+	ctx := context.Background()
+	log := r.Log.WithValues("servicebinding", req.NamespacedName)
+
+	sb := &corev1alpha1.ServiceBinding{}
+	if err := r.Client.Get(ctx, req.NamespacedName, sb); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !sb.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, sb)
+	}
+	if _, ok := injector.FindString(sb.Finalizers, finalizerName); !ok {
+		sb.Finalizers = append(sb.Finalizers, finalizerName)
+		if err := r.Client.Update(ctx, sb); err != nil {
+			return ctrl.Result{}, fmt.Errorf("add finalizer err: %w", err)
+		}
+	}
+	watchErrs, err := r.watchSources(ctx, sb)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("watch sources err: %w", err)
+	}
+	w := sb.Spec.WorkloadRef
+	if prev := sb.Status.InjectedWorkload; prev != nil && !sameWorkload(prev, w) {
+		// The workloadRef changed: remove the bindings from the workload
+		// it referred to before injecting the current one.
+		if err := r.uninjectWorkload(ctx, sb, prev); err != nil {
+			return ctrl.Result{}, err
+		}
+		orig := sb.DeepCopy()
+		sb.Status.InjectedWorkload = nil
+		if err := r.Client.Status().Patch(ctx, sb, client.MergeFrom(orig)); err != nil {
+			return ctrl.Result{}, fmt.Errorf("update status err: %w", err)
+		}
+	}
+	if w == nil {
+		return ctrl.Result{}, nil
+	}
+
+	workload, err := r.getWorkload(ctx, sb.Namespace, w)
+	if apierrors.IsNotFound(err) {
+		// The webhook injects the workload once it gets created.
+		log.Info("workload not found", "apiVersion", w.APIVersion, "kind", w.Kind, "name", w.Name)
+		res := &serviceBindingResult{
+			ServiceBinding: sb,
+			WorkloadErr:    err,
+			WatchErrs:      watchErrs,
+		}
+		if r.Recorder != nil {
+			r.Recorder.Eventf(sb, corev1.EventTypeNormal, reasonWorkloadNotFound, "Waiting for %s %s to be created", w.Kind, w.Name)
+		}
+		return ctrl.Result{}, r.updateStatus(ctx, res)
+	}
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("get workload err: %w", err)
+	}
+
+	areq, err := newWorkloadRequest(workload)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	result, err := r.injectServiceBindings(areq, []corev1alpha1.ServiceBinding{*sb})
+	if err == nil && len(result.Patches) > 0 {
+		err = r.patchWorkload(ctx, workload, result.Patches)
+		if err != nil {
+			invalid := apierrors.IsInvalid(err)
+			err = fmt.Errorf("patch workload err: %w", err)
+			for _, res := range result.Results {
+				res.InjectErrs = append(res.InjectErrs, err)
+				res.Injected = nil
+			}
+			if invalid {
+				// The workload rejects the patch, as a Job does for its
+				// immutable pod template, so retrying cannot succeed: the
+				// failure is reported in the status and events only.
+				log.Info("workload rejected the patch", "apiVersion", w.APIVersion, "kind", w.Kind, "name", w.Name, "error", err.Error())
+				err = nil
+			}
+		} else {
+			log.Info("injected workload", "apiVersion", w.APIVersion, "kind", w.Kind, "name", w.Name, "patches", len(result.Patches))
+		}
+	}
+	if result != nil {
+		for _, res := range result.Results {
+			res.WatchErrs = watchErrs
+		}
+		r.updateStatuses(ctx, result)
+		r.recordEvents(workload, result)
+	}
+	return ctrl.Result{}, err
+}
 
-	// your logic here
+// finalize removes the bindings injected by the ServiceBinding from its
+// workload, then the finalizer so that the ServiceBinding can be deleted.
+func (r *ServiceBindingReconciler) finalize(ctx context.Context, sb *corev1alpha1.ServiceBinding) error {
+	if _, ok := injector.FindString(sb.Finalizers, finalizerName); !ok {
+		return nil
+	}
 
-	return ctrl.Result{}, nil
+	if w := sb.Spec.WorkloadRef; w != nil {
+		if err := r.uninjectWorkload(ctx, sb, w); err != nil {
+			return err
+		}
+	}
+	if w := sb.Status.InjectedWorkload; w != nil && !sameWorkload(w, sb.Spec.WorkloadRef) {
+		if err := r.uninjectWorkload(ctx, sb, w); err != nil {
+			return err
+		}
+	}
+
+	var finalizers []string
+	for _, f := range sb.Finalizers {
+		if f != finalizerName {
+			finalizers = append(finalizers, f)
+		}
+	}
+	sb.Finalizers = finalizers
+	if err := r.Client.Update(ctx, sb); err != nil {
+		return fmt.Errorf("remove finalizer err: %w", err)
+	}
+	return nil
+}
+
+// uninjectWorkload removes the bindings injected by the ServiceBinding from
+// the workload. A workload that is gone, or that cannot be read or patched
+// for a reason retrying does not fix, keeps them.
+func (r *ServiceBindingReconciler) uninjectWorkload(ctx context.Context, sb *corev1alpha1.ServiceBinding, w *corev1alpha1.WorkloadReference) error {
+	log := r.Log.WithValues("servicebinding", path.Join(sb.Namespace, sb.Name))
+
+	workload, err := r.getWorkload(ctx, sb.Namespace, w)
+	switch {
+	case apierrors.IsNotFound(err) || meta.IsNoMatchError(err):
+		log.Info("workload not found", "apiVersion", w.APIVersion, "kind", w.Kind, "name", w.Name)
+		return nil
+	case isPermanent(err):
+		// The workload cannot be read, e.g. for lack of RBAC rules, and
+		// retrying will not change that, so the entries injected are
+		// left in place rather than blocking the ServiceBinding.
+		log.Info("workload not readable", "apiVersion", w.APIVersion, "kind", w.Kind, "name", w.Name, "error", err.Error())
+		if r.Recorder != nil {
+			r.Recorder.Eventf(sb, corev1.EventTypeWarning, reasonUninjectionFailed, "Bindings left in %s %s: %s", w.Kind, w.Name, err.Error())
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("get workload err: %w", err)
+	}
+
+	areq, err := newWorkloadRequest(workload)
+	if err != nil {
+		return err
+	}
+	patches, err := r.uninjectServiceBinding(areq, sb, w)
+	if err != nil {
+		return err
+	}
+	if len(patches) == 0 {
+		return nil
+	}
+	err = r.patchWorkload(ctx, workload, patches)
+	switch {
+	case apierrors.IsInvalid(err):
+		// The workload rejects the patch, as a Job does for its
+		// immutable pod template, so the entries injected are
+		// left in place rather than blocking the ServiceBinding.
+		log.Info("workload rejected the patch", "apiVersion", w.APIVersion, "kind", w.Kind, "name", w.Name, "error", err.Error())
+		if r.Recorder != nil {
+			r.Recorder.Eventf(sb, corev1.EventTypeWarning, reasonUninjectionFailed, "Bindings left in %s %s: %s", w.Kind, w.Name, err.Error())
+		}
+	case err != nil:
+		return fmt.Errorf("patch workload err: %w", err)
+	default:
+		log.Info("uninjected workload", "apiVersion", w.APIVersion, "kind", w.Kind, "name", w.Name, "patches", len(patches))
+	}
+	return nil
+}
+
+// sameWorkload tells whether the references refer to the same workload.
+func sameWorkload(a, b *corev1alpha1.WorkloadReference) bool {
+	return a != nil && b != nil && *a == *b
 }
 
 func (r *ServiceBindingReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&corev1alpha1.ServiceBinding{}).
-		Complete(r)
+		// roll workloads and update the copies of bound secrets when they change
+		Watches(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.secretServiceBindings),
+		}).
+		// update the managed data of services and templates when they change
+		Watches(&source.Kind{Type: &corev1.Service{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.serviceServiceBindings),
+		}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.configMapServiceBindings),
+		}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.Secret{}).
+		Build(r)
+	if err != nil {
+		return err
+	}
+	// sources of deferred bindings are watched as they are referenced
+	r.controller = c
+	r.mapper = mgr.GetRESTMapper()
+	return nil
 }
 
 func (r *ServiceBindingReconciler) ServeAdmission() {
@@ -104,23 +328,28 @@ func (r *ServiceBindingReconciler) handleMutateErr(w http.ResponseWriter, req *h
 		return fmt.Errorf("read request body err: %w", err)
 	}
 
-	review := &admissionv1beta1.AdmissionReview{}
-	if err := json.Unmarshal(body, review); err != nil {
-		return fmt.Errorf("unmarshal AdmissionReview err: %w", err)
+	review, err := decodeAdmissionReview(body)
+	if err != nil {
+		return fmt.Errorf("decode AdmissionReview err: %w", err)
 	}
 
-	patches, err := r.handleAdmissionRequest(review.Request)
+	// Failures to inject are answered with a denying response rather than
+	// an HTTP error, which the API server would treat as a webhook outage.
+	result, err := r.handleAdmissionRequest(review.Request)
 	if err != nil {
-		return fmt.Errorf("handleAdmissionRequest err: %w", err)
+		r.Log.Error(err, "deny admission request", "request", path.Join(review.Request.Namespace, review.Request.Name))
+	}
+	if result != nil && !isDryRun(review.Request) {
+		r.updateStatuses(req.Context(), result)
+		r.recordEvents(requestWorkload(review.Request), result)
 	}
 
-	// write back response
-	p, err := json.Marshal(patches)
+	// write back response in the same version as the request
+	resp, err := newAdmissionResponse(review, result, err)
 	if err != nil {
 		return err
 	}
-	review.Response = newAdmissionResponse(review, p)
-	b, err := json.Marshal(review)
+	b, err := review.marshal(resp)
 	if err != nil {
 		return fmt.Errorf("marshal AdmissionReview err: %w", err)
 	}
@@ -130,33 +359,40 @@ func (r *ServiceBindingReconciler) handleMutateErr(w http.ResponseWriter, req *h
 	return nil
 }
 
-func newAdmissionResponse(review *admissionv1beta1.AdmissionReview, patch []byte) *admissionv1beta1.AdmissionResponse {
-	resp := &admissionv1beta1.AdmissionResponse{}
-	// set response options
-	resp.Allowed = true
-	resp.UID = review.Request.UID
-	pT := admissionv1beta1.PatchTypeJSONPatch
-	resp.PatchType = &pT
+// admissionResult is the combined outcome of injecting all ServiceBindings
+// that target the workload of an admission request.
+type admissionResult struct {
+	// Patches is the JSON patch to apply to the workload.
+	Patches []webhook.JSONPatchOp
 
-	resp.Patch = patch
-	resp.Result = &metav1.Status{
-		Status: "Success",
-	}
+	// ServiceBindings are the names of the ServiceBindings applied, in order.
+	ServiceBindings []string
+
+	// Warnings describe the bindings skipped because of their Ignore failure policy.
+	Warnings []string
 
-	return resp
+	// Results are the outcomes of the ServiceBindings attempted, in order.
+	Results []*serviceBindingResult
 }
 
-func (r *ServiceBindingReconciler) handleAdmissionRequest(req *admissionv1beta1.AdmissionRequest) ([]webhook.JSONPatchOp, error) {
-	// Search any ServiceBinding whose target matches the given request.
+func (r *ServiceBindingReconciler) handleAdmissionRequest(req *admissionv1beta1.AdmissionRequest) (*admissionResult, error) {
+	// Search all ServiceBindings whose target matches the given request.
 	sbl := &corev1alpha1.ServiceBindingList{}
 	err := r.Client.List(context.TODO(), sbl, client.InNamespace(req.Namespace))
 	if err != nil {
 		return nil, fmt.Errorf("list servicebindings err: %w", err)
 	}
 
-	var sb *corev1alpha1.ServiceBinding
+	var sbs []corev1alpha1.ServiceBinding
 	for _, item := range sbl.Items {
 		w := item.Spec.WorkloadRef
+		if w == nil {
+			continue
+		}
+		// ServiceBindings being deleted are removed from their workload.
+		if !item.DeletionTimestamp.IsZero() {
+			continue
+		}
 		r.Log.Info("kind matching", "apiVersion", w.APIVersion, "kind", w.Kind, "name", w.Name, "request", req.Kind.String()+", "+req.Namespace+"/"+w.Name)
 		rk := req.Kind
 		gv := rk.Version
@@ -167,52 +403,322 @@ func (r *ServiceBindingReconciler) handleAdmissionRequest(req *admissionv1beta1.
 			continue
 		}
 		if rk.Kind == w.Kind && gv == w.APIVersion && req.Name == w.Name {
-			sb = &item
-			break
+			sbs = append(sbs, item)
 		}
 	}
-	if sb == nil {
+	if len(sbs) == 0 {
 		r.Log.Info("uninterested request", "request", path.Join(req.Namespace, req.Name))
-		return nil, nil
+		return &admissionResult{}, nil
 	}
 
-	for _, b := range sb.Spec.Bindings {
-		switch {
-		case b.From.Secret != nil:
-			r.Log.Info("trying to inject secret")
-			return r.injectSecret(req, sb.Spec.WorkloadRef, b)
-		case b.From.Volume != nil:
-			r.Log.Info("trying to inject volume")
-			return r.injectVolume(req, sb.Spec.WorkloadRef, b)
+	// Apply ServiceBindings in name order so that the combined patch is
+	// deterministic regardless of the order they were listed in.
+	sort.Slice(sbs, func(i, j int) bool {
+		return sbs[i].Name < sbs[j].Name
+	})
+	return r.injectServiceBindings(req, sbs)
+}
+
+// resolvedBinding is a binding whose source was resolved, or failed to.
+type resolvedBinding struct {
+	status corev1alpha1.BindingStatus
+	values map[string]interface{}
+	err    error
+}
+
+// injectServiceBindings injects the bindings of the given ServiceBindings, in
+// order, into the workload of the request, and records the applied ones on
+// the workload. Target injectors skip data already injected, so a workload
+// can be injected again on update or webhook reinvocation. The entries
+// injected for an earlier generation of a ServiceBinding, or for sources since
+// resolved to other objects, are removed first, so that the workload only
+// holds the ones of its current spec and sources. When a binding
+// fails, the returned result still holds the outcomes of the ServiceBindings
+// attempted so far.
+func (r *ServiceBindingReconciler) injectServiceBindings(req *admissionv1beta1.AdmissionRequest, sbs []corev1alpha1.ServiceBinding) (*admissionResult, error) {
+	injected, err := injectedServiceBindings(req.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &admissionResult{}
+	obj := req.Object
+	for i := range sbs {
+		sb := &sbs[i]
+		res := &serviceBindingResult{
+			ServiceBinding: sb,
+		}
+		result.Results = append(result.Results, res)
+
+		// Sources are resolved before anything is injected, as the entries
+		// of sources resolved to other objects than recorded are removed
+		// first.
+		resolved := make([]resolvedBinding, len(sb.Spec.Bindings))
+		sources := make([]string, len(sb.Spec.Bindings))
+		for j, b := range sb.Spec.Bindings {
+			rb := &resolved[j]
+			rb.status.Index = j
+			rb.values, rb.err = r.resolveBinding(req, sb, j, b)
+			if rb.err != nil {
+				continue
+			}
+			setBindingNames(&rb.status, rb.values)
+			sources[j] = bindingSource(rb.status)
+			if len(rb.status.SecretName) != 0 && !r.secretExists(req.Namespace, rb.status.SecretName) {
+				res.MissingSecrets = append(res.MissingSecrets, rb.status.SecretName)
+			}
+			if b.To.BindingRoot != nil {
+				rb.values, rb.err = r.resolveBindingRoot(req, sb, j, rb.values)
+			}
+		}
+
+		record := injected[sb.Name]
+		if record != nil && (record.Generation != sb.Generation || sourcesChanged(record.Sources, sources)) {
+			// The bindings or their sources changed: remove the entries
+			// injected before, which may not be injected anymore.
+			var p []webhook.JSONPatchOp
+			obj, p, err = r.uninjectRecorded(req, obj, sb, sb.Spec.WorkloadRef, injected)
+			if err != nil {
+				return result, err
+			}
+			result.Patches = append(result.Patches, p...)
+			record.Injected = nil
+			record.Sources = nil
+		}
+		if record == nil {
+			record = &injectedServiceBinding{}
+		}
+		if record.Injected == nil {
+			record.Injected = &plugin.Injection{}
+		}
+		shared := sharedInjections(injected, sb.Name)
+
+		for j, b := range sb.Spec.Bindings {
+			bs := resolved[j].status
+			values, err := resolved[j].values, resolved[j].err
+			var p []webhook.JSONPatchOp
+			if err != nil {
+				// The entries of an unresolved source stay recorded.
+				if j < len(record.Sources) {
+					sources[j] = record.Sources[j]
+				}
+				err = fmt.Errorf("servicebinding %s bindings[%d]: %w", sb.Name, j, err)
+				// The reconciler injects the binding once its source resolves.
+				if b.FailurePolicy == corev1alpha1.FailurePolicyDefer {
+					r.Log.Info("defer unresolved binding", "request", path.Join(req.Namespace, req.Name), "error", err.Error())
+					res.DeferredErrs = append(res.DeferredErrs, err)
+					res.Bindings = append(res.Bindings, bs)
+					result.Warnings = append(result.Warnings, err.Error())
+					continue
+				}
+				res.ResolveErrs = append(res.ResolveErrs, err)
+			} else if p, err = r.injectBinding(req, obj, sb.Spec.WorkloadRef, plugin.TargetContext{
+				Binding:  &b,
+				Values:   values,
+				Injected: record.Injected,
+				Shared:   shared,
+			}); err == errUnsupportedWorkload {
+				res.Unsupported = true
+				err = nil
+			} else if err != nil {
+				err = fmt.Errorf("servicebinding %s bindings[%d]: %w", sb.Name, j, err)
+				res.InjectErrs = append(res.InjectErrs, err)
+			}
+			if err != nil {
+				res.Bindings = append(res.Bindings, bs)
+				if b.FailurePolicy == corev1alpha1.FailurePolicyIgnore {
+					r.Log.Info("ignore failed binding", "request", path.Join(req.Namespace, req.Name), "error", err.Error())
+					result.Warnings = append(result.Warnings, err.Error())
+					continue
+				}
+				return result, err
+			}
+			if res.Unsupported {
+				res.Bindings = append(res.Bindings, bs)
+				continue
+			}
+
+			// Later bindings are injected into the object as patched by the
+			// earlier ones, so that array initialisation ops are only emitted
+			// once and container indices stay valid in the combined patch.
+			if len(p) > 0 {
+				obj, err = applyPatches(obj, p)
+				if err != nil {
+					return result, fmt.Errorf("apply patches err: %w", err)
+				}
+				result.Patches = append(result.Patches, p...)
+			}
+
+			containers, err := r.selectedContainers(req, obj, sb.Spec.WorkloadRef, b.ContainerSelector)
+			if err != nil {
+				return result, err
+			}
+			bs.Workloads = []corev1alpha1.BoundWorkload{{
+				WorkloadReference: *sb.Spec.WorkloadRef,
+				Containers:        containers,
+			}}
+			res.Bindings = append(res.Bindings, bs)
+			if len(p) > 0 {
+				res.Injected = append(res.Injected, injection{
+					Source:     bindingSource(bs),
+					Containers: containers,
+				})
+			}
 		}
+		record.Generation = sb.Generation
+		record.Sources = sources
+		injected[sb.Name] = record
+		result.ServiceBindings = append(result.ServiceBindings, sb.Name)
 	}
-	return nil, nil
+	if len(result.ServiceBindings) == 0 {
+		return result, nil
+	}
+
+	p, err := recordInjected(obj, injected)
+	if err != nil {
+		return result, err
+	}
+	result.Patches = append(result.Patches, p...)
+	r.Log.Info("injected servicebindings", "request", path.Join(req.Namespace, req.Name), "servicebindings", result.ServiceBindings, "patches", len(result.Patches))
+	return result, nil
 }
 
-func (r *ServiceBindingReconciler) injectVolume(req *admissionv1beta1.AdmissionRequest, w *corev1alpha1.WorkloadReference, b corev1alpha1.Binding) ([]webhook.JSONPatchOp, error) {
-	if ok, p, err := inject2workload(plugin.TargetContext{
-		Binding: &b,
-		Values: map[string]interface{}{
-			"pvc-name": b.From.Volume.PVCName,
-		},
-	}, req, w); ok {
-		return p, err
-	} else {
-		r.Log.Info("unsupported target kind ", "apiVersion", w.APIVersion, "kind", w.Kind, "name", w.Name)
+// uninjectServiceBinding returns the patches removing the entries recorded as
+// injected for the ServiceBinding from the workload of the request, if it was
+// injected. The entries recorded are removed rather than the ones the
+// bindings would inject now, as their sources may have changed or may not
+// resolve anymore.
+func (r *ServiceBindingReconciler) uninjectServiceBinding(req *admissionv1beta1.AdmissionRequest, sb *corev1alpha1.ServiceBinding, w *corev1alpha1.WorkloadReference) ([]webhook.JSONPatchOp, error) {
+	injected, err := injectedServiceBindings(req.Object)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := injected[sb.Name]; !ok {
 		return nil, nil
 	}
+
+	obj, patches, err := r.uninjectRecorded(req, req.Object, sb, w, injected)
+	if err != nil {
+		return nil, err
+	}
+	delete(injected, sb.Name)
+	p, err := recordInjected(obj, injected)
+	if err != nil {
+		return nil, err
+	}
+	return append(patches, p...), nil
 }
 
-func (r *ServiceBindingReconciler) injectSecret(req *admissionv1beta1.AdmissionRequest, w *corev1alpha1.WorkloadReference, b corev1alpha1.Binding) ([]webhook.JSONPatchOp, error) {
-	s := b.From.Secret
+// uninjectRecorded returns the object of the workload and the patches
+// removing the entries recorded as injected for the ServiceBinding from it,
+// but the ones shared with the other ServiceBindings.
+func (r *ServiceBindingReconciler) uninjectRecorded(req *admissionv1beta1.AdmissionRequest, obj runtime.RawExtension, sb *corev1alpha1.ServiceBinding, w *corev1alpha1.WorkloadReference, injected map[string]*injectedServiceBinding) (runtime.RawExtension, []webhook.JSONPatchOp, error) {
+	record := injected[sb.Name]
+	if record == nil || record.Injected == nil {
+		return obj, nil, nil
+	}
+	ok, p, err := r.uninject2workload(plugin.TargetContext{
+		Injected: record.Injected,
+		Shared:   sharedInjections(injected, sb.Name),
+	}, req, obj, w)
+	if err != nil {
+		return obj, nil, fmt.Errorf("servicebinding %s: %w", sb.Name, err)
+	}
+	if !ok || len(p) == 0 {
+		return obj, nil, nil
+	}
+	obj, err = applyPatches(obj, p)
+	if err != nil {
+		return obj, nil, fmt.Errorf("apply patches err: %w", err)
+	}
+	return obj, p, nil
+}
 
-	secretName := s.Name
+// resolveBinding resolves the data source of the binding at the index into
+// the values passed to target injectors. The data of sources injected through
+// objects managed by the ServiceBinding is written unless the request is a
+// dry run.
+func (r *ServiceBindingReconciler) resolveBinding(req *admissionv1beta1.AdmissionRequest, sb *corev1alpha1.ServiceBinding, index int, b corev1alpha1.Binding) (map[string]interface{}, error) {
+	namespace := req.Namespace
+	switch {
+	case b.From.Secret != nil && len(b.From.Secret.Namespace) != 0 && b.From.Secret.Namespace != namespace:
+		return r.resolveMirror(req, sb, index, b.From.Secret)
+	case b.From.Secret != nil:
+		secretName, err := r.resolveSourceName(namespace, b.From.Secret.Name, b.From.Secret.NameFromField)
+		if err != nil {
+			return nil, err
+		}
+		values := map[string]interface{}{
+			"secret-name": secretName,
+		}
+		if b.From.Secret.RolloutOnChange {
+			checksum, err := r.secretChecksum(namespace, secretName)
+			if err != nil {
+				return nil, err
+			}
+			if len(checksum) != 0 {
+				values["secret-checksum"] = checksum
+			}
+		}
+		return values, nil
+	case b.From.ConfigMap != nil:
+		configMapName, err := r.resolveSourceName(namespace, b.From.ConfigMap.Name, b.From.ConfigMap.NameFromField)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"configmap-name": configMapName,
+		}, nil
+	case b.From.Service != nil:
+		serviceName, data, err := r.resolveService(namespace, b.From.Service)
+		if err != nil {
+			return nil, err
+		}
+		configMapName := managedName(sb, "service", index)
+		if !isDryRun(req) {
+			if err := r.ensureManagedConfigMap(sb, configMapName, data); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]interface{}{
+			"service-name":   serviceName,
+			"configmap-name": configMapName,
+		}, nil
+	case b.From.Template != nil:
+		data, err := r.renderTemplate(namespace, b.From.Template)
+		if err != nil {
+			return nil, err
+		}
+		secretName := managedName(sb, "template", index)
+		if !isDryRun(req) {
+			if err := r.ensureManagedSecret(sb, secretName, data); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]interface{}{
+			"secret-name": secretName,
+		}, nil
+	case b.From.Volume != nil:
+		return map[string]interface{}{
+			"pvc-name": b.From.Volume.PVCName,
+		}, nil
+	case b.From.DownwardAPI != nil || b.From.ServiceAccountToken != nil:
+		if len(b.To.FilePath) == 0 || b.To.Env || len(b.To.EnvMappings) != 0 {
+			return nil, newStatusError(metav1.StatusReasonInvalid, http.StatusUnprocessableEntity, errors.New("downwardAPI and serviceAccountToken sources only apply to a filePath target"))
+		}
+		return map[string]interface{}{}, nil
+	}
+	return nil, nil
+}
 
-	// Read secret name from an object's field
-	if f := s.NameFromField; f != nil {
+// resolveSourceName returns the name of a binding source, read from the
+// object field if given.
+func (r *ServiceBindingReconciler) resolveSourceName(namespace, name string, f *corev1alpha1.SecretNameFromField) (string, error) {
+	// Read source name from an object's field
+	if f != nil {
 		gv, err := schema.ParseGroupVersion(f.APIVersion)
 		if err != nil {
-			return nil, err
+			return "", newStatusError(metav1.StatusReasonInvalid, http.StatusUnprocessableEntity, fmt.Errorf("nameFromField apiVersion: %w", err))
 		}
 		u := &unstructured.Unstructured{}
 		u.SetGroupVersionKind(schema.GroupVersionKind{
@@ -221,52 +727,74 @@ func (r *ServiceBindingReconciler) injectSecret(req *admissionv1beta1.AdmissionR
 			Kind:    f.Kind,
 		})
 		err = r.Client.Get(context.Background(), client.ObjectKey{
-			Namespace: req.Namespace,
+			Namespace: namespace,
 			Name:      f.Name,
 		}, u)
 		if err != nil {
-			return nil, err
+			return "", fmt.Errorf("get %s %s: %w", f.Kind, f.Name, err)
 		}
-		arr := strings.Split(f.FieldPath, ".")
-		found := false
-		if len(arr) > 1 {
-			fields := arr[1:]
-			secretName, found, err = unstructured.NestedString(u.Object, fields...)
-			if err != nil {
-				return nil, err
-			}
+		name, err = fieldpath.String(u.Object, f.FieldPath)
+		if errors.Is(err, fieldpath.ErrNotFound) {
+			return "", newStatusError(metav1.StatusReasonNotFound, http.StatusNotFound, fmt.Errorf("%s %s: %w", f.Kind, f.Name, err))
 		}
-		if !found {
-			return nil, fmt.Errorf("fieldPath not found: %s", f.FieldPath)
+		if err != nil {
+			return "", newStatusError(metav1.StatusReasonInvalid, http.StatusUnprocessableEntity, fmt.Errorf("%s %s: %w", f.Kind, f.Name, err))
 		}
 	}
+	return name, nil
+}
 
-	if ok, p, err := inject2workload(plugin.TargetContext{
-		Binding: &b,
-		Values: map[string]interface{}{
-			"secret-name": secretName,
-		},
-	}, req, w); ok {
+// errUnsupportedWorkload is returned by injectBinding if no target injector
+// matches the workload.
+var errUnsupportedWorkload = errors.New("unsupported workload kind")
+
+func (r *ServiceBindingReconciler) injectBinding(req *admissionv1beta1.AdmissionRequest, obj runtime.RawExtension, w *corev1alpha1.WorkloadReference, pctx plugin.TargetContext) ([]webhook.JSONPatchOp, error) {
+	if ok, p, err := r.inject2workload(pctx, req, obj, w); ok {
 		return p, err
-	} else {
-		r.Log.Info("unsupported target kind ", "apiVersion", w.APIVersion, "kind", w.Kind, "name", w.Name)
-		return nil, nil
 	}
+	r.Log.Info("unsupported target kind ", "apiVersion", w.APIVersion, "kind", w.Kind, "name", w.Name)
+	return nil, errUnsupportedWorkload
 }
 
-func inject2workload(pctx plugin.TargetContext, req *admissionv1beta1.AdmissionRequest, w *corev1alpha1.WorkloadReference) (bool, []webhook.JSONPatchOp, error) {
-	for _, injector := range plugin.TargetInjectors {
-		if !injector.Match(req, w) {
-			continue
-		}
+func (r *ServiceBindingReconciler) inject2workload(pctx plugin.TargetContext, req *admissionv1beta1.AdmissionRequest, obj runtime.RawExtension, w *corev1alpha1.WorkloadReference) (bool, []webhook.JSONPatchOp, error) {
+	injector := r.Injectors.Find(req, w)
+	if injector == nil {
+		return false, nil, nil
+	}
+	p, err := injector.Inject(pctx, obj)
+	if err != nil {
+		return true, nil, fmt.Errorf("%s: %w", injector.Name(), err)
+	}
+	return true, p, nil
+}
 
-		p, err := injector.Inject(pctx, req.Object)
-		if err != nil {
-			panic(err)
-		}
-		return true, p, nil
+func (r *ServiceBindingReconciler) uninject2workload(pctx plugin.TargetContext, req *admissionv1beta1.AdmissionRequest, obj runtime.RawExtension, w *corev1alpha1.WorkloadReference) (bool, []webhook.JSONPatchOp, error) {
+	injector := r.Injectors.Find(req, w)
+	if injector == nil {
+		return false, nil, nil
+	}
+	p, err := injector.Uninject(pctx, obj)
+	if err != nil {
+		return true, nil, fmt.Errorf("%s: %w", injector.Name(), err)
+	}
+	return true, p, nil
+}
+
+// applyPatches returns the object with the given JSON patches applied.
+func applyPatches(obj runtime.RawExtension, patches []webhook.JSONPatchOp) (runtime.RawExtension, error) {
+	b, err := json.Marshal(patches)
+	if err != nil {
+		return obj, err
+	}
+	p, err := jsonpatch.DecodePatch(b)
+	if err != nil {
+		return obj, err
+	}
+	patched, err := p.Apply(obj.Raw)
+	if err != nil {
+		return obj, err
 	}
-	return false, nil, nil
+	return runtime.RawExtension{Raw: patched}, nil
 }
 
 func healthCheck(w http.ResponseWriter, req *http.Request) {
@@ -0,0 +1,159 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Reasons of the ServiceBinding conditions.
+const (
+	reasonResolved            = "Resolved"
+	reasonSourceNotResolved   = "SourceNotResolved"
+	reasonWaitingForSource    = "WaitingForSource"
+	reasonSourceNotWatched    = "SourceNotWatched"
+	reasonInjected            = "Injected"
+	reasonInjectionFailed     = "InjectionFailed"
+	reasonWorkloadNotFound    = "WorkloadNotFound"
+	reasonUnsupportedWorkload = "UnsupportedWorkload"
+)
+
+// serviceBindingResult is the outcome of injecting a ServiceBinding into its
+// workload, reported in the ServiceBinding status.
+type serviceBindingResult struct {
+	ServiceBinding *corev1alpha1.ServiceBinding
+
+	// Bindings are the status of the bindings attempted, in order.
+	Bindings []corev1alpha1.BindingStatus
+
+	// ResolveErrs are the failures to resolve binding sources, including ignored ones.
+	ResolveErrs []error
+
+	// DeferredErrs are the failures to resolve binding sources deferred until they resolve.
+	DeferredErrs []error
+
+	// InjectErrs are the failures to inject bindings, including ignored ones.
+	InjectErrs []error
+
+	// WorkloadErr is the failure to get the workload.
+	WorkloadErr error
+
+	// Unsupported is set if no target injector supports the workload.
+	Unsupported bool
+
+	// Injected are the bindings newly injected into the workload.
+	Injected []injection
+
+	// MissingSecrets are the names of the resolved secrets that do not exist.
+	MissingSecrets []string
+
+	// WatchErrs are the failures to watch the kinds of the objects binding
+	// sources are read from.
+	WatchErrs []error
+}
+
+// updateStatuses updates the status of each ServiceBinding attempted.
+// Failures are logged, as the result has already been applied.
+func (r *ServiceBindingReconciler) updateStatuses(ctx context.Context, result *admissionResult) {
+	for _, res := range result.Results {
+		if err := r.updateStatus(ctx, res); err != nil {
+			r.Log.Error(err, "update servicebinding status", "servicebinding", res.ServiceBinding.Namespace+"/"+res.ServiceBinding.Name)
+		}
+	}
+}
+
+func (r *ServiceBindingReconciler) updateStatus(ctx context.Context, res *serviceBindingResult) error {
+	sb := res.ServiceBinding
+	orig := sb.DeepCopy()
+
+	st := &sb.Status
+	st.ObservedGeneration = sb.Generation
+	st.Bindings = res.Bindings
+	// A previous workload is kept until the reconciler removes the bindings
+	// from it.
+	if st.InjectedWorkload == nil && res.WorkloadErr == nil && !res.Unsupported && sb.Spec.WorkloadRef != nil {
+		w := *sb.Spec.WorkloadRef
+		st.InjectedWorkload = &w
+	}
+
+	resolved := newCondition(sb, corev1alpha1.ConditionSourceResolved, reasonResolved, "")
+	switch {
+	case len(res.ResolveErrs) > 0:
+		resolved = newFalseCondition(sb, corev1alpha1.ConditionSourceResolved, reasonSourceNotResolved, joinErrors(res.ResolveErrs))
+	case len(res.WatchErrs) > 0:
+		// deferred bindings would wait for a change never seen
+		resolved = newFalseCondition(sb, corev1alpha1.ConditionSourceResolved, reasonSourceNotWatched, joinErrors(res.WatchErrs))
+	case len(res.DeferredErrs) > 0:
+		resolved = newFalseCondition(sb, corev1alpha1.ConditionSourceResolved, reasonWaitingForSource, joinErrors(res.DeferredErrs))
+	}
+
+	injected := newCondition(sb, corev1alpha1.ConditionInjected, reasonInjected, "")
+	switch {
+	case res.WorkloadErr != nil:
+		injected = newFalseCondition(sb, corev1alpha1.ConditionInjected, reasonWorkloadNotFound, res.WorkloadErr.Error())
+	case res.Unsupported:
+		injected = newFalseCondition(sb, corev1alpha1.ConditionInjected, reasonUnsupportedWorkload, "no target injector supports "+sb.Spec.WorkloadRef.APIVersion+" "+sb.Spec.WorkloadRef.Kind)
+	case len(res.InjectErrs) > 0:
+		injected = newFalseCondition(sb, corev1alpha1.ConditionInjected, reasonInjectionFailed, joinErrors(res.InjectErrs))
+	}
+
+	ready := newCondition(sb, corev1alpha1.ConditionReady, reasonInjected, "")
+	switch {
+	case resolved.Status != metav1.ConditionTrue:
+		ready = newFalseCondition(sb, corev1alpha1.ConditionReady, resolved.Reason, resolved.Message)
+	case injected.Status != metav1.ConditionTrue:
+		ready = newFalseCondition(sb, corev1alpha1.ConditionReady, injected.Reason, injected.Message)
+	}
+
+	st.SetCondition(resolved)
+	st.SetCondition(injected)
+	st.SetCondition(ready)
+	return r.Client.Status().Patch(ctx, sb, client.MergeFrom(orig))
+}
+
+func newCondition(sb *corev1alpha1.ServiceBinding, t corev1alpha1.ConditionType, reason, message string) corev1alpha1.Condition {
+	return corev1alpha1.Condition{
+		Type:               t,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: sb.Generation,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+func newFalseCondition(sb *corev1alpha1.ServiceBinding, t corev1alpha1.ConditionType, reason, message string) corev1alpha1.Condition {
+	c := newCondition(sb, t, reason, message)
+	c.Status = metav1.ConditionFalse
+	return c
+}
+
+func joinErrors(errs []error) string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func isDryRun(req *admissionv1beta1.AdmissionRequest) bool {
+	return req.DryRun != nil && *req.DryRun
+}
@@ -0,0 +1,199 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"text/template"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// renderTemplate returns the data of the templates rendered over their inputs.
+func (r *ServiceBindingReconciler) renderTemplate(namespace string, t *corev1alpha1.TemplateSource) (map[string][]byte, error) {
+	inputs := map[string]interface{}{}
+	for _, in := range t.Inputs {
+		v, err := r.resolveTemplateInput(namespace, in)
+		if err != nil {
+			return nil, fmt.Errorf("template input %s: %w", in.Name, err)
+		}
+		inputs[in.Name] = v
+	}
+
+	keys := make([]string, 0, len(t.Data))
+	for k := range t.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	data := map[string][]byte{}
+	for _, k := range keys {
+		tpl, err := template.New(k).Option("missingkey=error").Parse(t.Data[k])
+		if err != nil {
+			return nil, newStatusError(metav1.StatusReasonInvalid, http.StatusUnprocessableEntity, fmt.Errorf("parse template %s: %w", k, err))
+		}
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, inputs); err != nil {
+			return nil, newStatusError(metav1.StatusReasonInvalid, http.StatusUnprocessableEntity, fmt.Errorf("render template %s: %w", k, err))
+		}
+		data[k] = buf.Bytes()
+	}
+	return data, nil
+}
+
+// resolveTemplateInput returns the keys of the secret or configmap of the
+// input, or the value of its object field.
+func (r *ServiceBindingReconciler) resolveTemplateInput(namespace string, in corev1alpha1.TemplateInput) (interface{}, error) {
+	ctx := context.Background()
+	switch {
+	case in.Secret != nil && len(in.Secret.Namespace) != 0 && in.Secret.Namespace != namespace:
+		secret, _, err := r.getMirroredSecret(namespace, in.Secret)
+		if err != nil {
+			return nil, err
+		}
+		keys := map[string]string{}
+		for k, v := range secret.Data {
+			keys[k] = string(v)
+		}
+		return keys, nil
+	case in.Secret != nil:
+		name, err := r.resolveSourceName(namespace, in.Secret.Name, in.Secret.NameFromField)
+		if err != nil {
+			return nil, err
+		}
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret); err != nil {
+			return nil, fmt.Errorf("get secret %s: %w", name, err)
+		}
+		keys := map[string]string{}
+		for k, v := range secret.Data {
+			keys[k] = string(v)
+		}
+		return keys, nil
+	case in.ConfigMap != nil:
+		name, err := r.resolveSourceName(namespace, in.ConfigMap.Name, in.ConfigMap.NameFromField)
+		if err != nil {
+			return nil, err
+		}
+		cm := &corev1.ConfigMap{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cm); err != nil {
+			return nil, fmt.Errorf("get configmap %s: %w", name, err)
+		}
+		keys := map[string]string{}
+		for k, v := range cm.Data {
+			keys[k] = v
+		}
+		return keys, nil
+	case in.Field != nil:
+		return r.resolveSourceName(namespace, "", in.Field)
+	}
+	return nil, newStatusError(metav1.StatusReasonInvalid, http.StatusUnprocessableEntity, errors.New("one of secret, configMap or field is required"))
+}
+
+// ensureManagedSecret creates or updates the secret with the data, owned by
+// the ServiceBinding so that it is deleted along with it. An existing secret
+// the ServiceBinding does not control is left alone.
+func (r *ServiceBindingReconciler) ensureManagedSecret(sb *corev1alpha1.ServiceBinding, name string, data map[string][]byte) error {
+	secret := &corev1.Secret{}
+	secret.Namespace = sb.Namespace
+	secret.Name = name
+	_, err := controllerutil.CreateOrUpdate(context.Background(), r.Client, secret, func() error {
+		if len(secret.ResourceVersion) != 0 && !metav1.IsControlledBy(secret, sb) {
+			return newStatusError(metav1.StatusReasonConflict, http.StatusConflict, fmt.Errorf("secret %s is not managed by servicebinding %s", name, sb.Name))
+		}
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		secret.Labels[managedLabel] = sb.Name
+		secret.Data = data
+		return controllerutil.SetControllerReference(sb, secret, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("update secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// templateInputRefers tells whether an input of the template binding may read
+// the secret or configmap of the given name. Inputs reading their name from an
+// object field may refer to any.
+func templateInputRefers(b corev1alpha1.Binding, secret bool, name string) bool {
+	if b.From.Template == nil {
+		return false
+	}
+	for _, in := range b.From.Template.Inputs {
+		switch {
+		case secret && in.Secret != nil:
+			if in.Secret.NameFromField != nil || in.Secret.Name == name {
+				return true
+			}
+		case !secret && in.ConfigMap != nil:
+			if in.ConfigMap.NameFromField != nil || in.ConfigMap.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// configMapServiceBindings maps a configmap to the ServiceBindings copying it
+// under the binding root or rendering templates over it.
+func (r *ServiceBindingReconciler) configMapServiceBindings(o handler.MapObject) []reconcile.Request {
+	sbl := &corev1alpha1.ServiceBindingList{}
+	if err := r.Client.List(context.Background(), sbl, client.InNamespace(o.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "list servicebindings", "configmap", o.Meta.GetNamespace()+"/"+o.Meta.GetName())
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for i := range sbl.Items {
+		sb := &sbl.Items[i]
+		for j, b := range sb.Spec.Bindings {
+			bound := b.From.ConfigMap != nil && b.To.BindingRoot != nil && boundConfigMapName(sb, j) == o.Meta.GetName()
+			if bound || templateInputRefers(b, false, o.Meta.GetName()) {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: sb.Namespace,
+					Name:      sb.Name,
+				}})
+				break
+			}
+		}
+	}
+	return reqs
+}
+
+// boundConfigMapName returns the name of the configmap of the binding at the
+// index, as resolved in the ServiceBinding status if read from an object field.
+func boundConfigMapName(sb *corev1alpha1.ServiceBinding, index int) string {
+	for _, bs := range sb.Status.Bindings {
+		if bs.Index == index && len(bs.ConfigMapName) != 0 {
+			return bs.ConfigMapName
+		}
+	}
+	return sb.Spec.Bindings[index].From.ConfigMap.Name
+}
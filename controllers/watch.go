@@ -0,0 +1,147 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// watchSources watches the kinds of the objects the deferred bindings of the
+// ServiceBinding read their source name from, so that the ServiceBinding is
+// reconciled once the field appears, and of the objects its templates read
+// fields from, so that they are rendered again when the fields change. Each
+// kind is watched once. A kind the injector is not allowed to list and watch
+// is not watched, and the failure returned to be reported in the status, as
+// its watch would never sync.
+func (r *ServiceBindingReconciler) watchSources(ctx context.Context, sb *corev1alpha1.ServiceBinding) ([]error, error) {
+	if r.controller == nil {
+		return nil, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var watchErrs []error
+	for _, f := range watchedFields(sb) {
+		gv, err := schema.ParseGroupVersion(f.APIVersion)
+		if err != nil {
+			// reported when resolving the binding
+			continue
+		}
+		gvk := gv.WithKind(f.Kind)
+		if r.watched[gvk] {
+			continue
+		}
+		if r.mapper != nil {
+			if err := checkAccess(ctx, r.Client, r.mapper, gvk, "list", "watch"); err != nil {
+				watchErrs = append(watchErrs, fmt.Errorf("watch %s %s: %w", f.APIVersion, f.Kind, err))
+				continue
+			}
+		}
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		err = r.controller.Watch(&source.Kind{Type: u}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.sourceServiceBindings),
+		})
+		if err != nil {
+			return watchErrs, err
+		}
+		if r.watched == nil {
+			r.watched = map[schema.GroupVersionKind]bool{}
+		}
+		r.watched[gvk] = true
+		r.Log.Info("watching binding sources", "apiVersion", f.APIVersion, "kind", f.Kind)
+	}
+	return watchErrs, nil
+}
+
+// sourceServiceBindings maps an object to the ServiceBindings whose deferred
+// bindings or templates read fields from it.
+func (r *ServiceBindingReconciler) sourceServiceBindings(o handler.MapObject) []reconcile.Request {
+	gvk := o.Object.GetObjectKind().GroupVersionKind()
+	sbl := &corev1alpha1.ServiceBindingList{}
+	if err := r.Client.List(context.Background(), sbl, client.InNamespace(o.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "list servicebindings", "kind", gvk.Kind, "name", o.Meta.GetNamespace()+"/"+o.Meta.GetName())
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, sb := range sbl.Items {
+		for _, f := range watchedFields(&sb) {
+			if f.Kind != gvk.Kind || f.APIVersion != gvk.GroupVersion().String() || f.Name != o.Meta.GetName() {
+				continue
+			}
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: sb.Namespace,
+				Name:      sb.Name,
+			}})
+			break
+		}
+	}
+	return reqs
+}
+
+// watchedFields returns the object fields the ServiceBinding reads that are
+// watched for changes: the source names of deferred bindings and the fields
+// template inputs read.
+func watchedFields(sb *corev1alpha1.ServiceBinding) []*corev1alpha1.SecretNameFromField {
+	var fields []*corev1alpha1.SecretNameFromField
+	for _, b := range sb.Spec.Bindings {
+		if f := deferredNameFromField(b); f != nil {
+			fields = append(fields, f)
+		}
+		if b.From.Template == nil {
+			continue
+		}
+		for _, in := range b.From.Template.Inputs {
+			switch {
+			case in.Field != nil:
+				fields = append(fields, in.Field)
+			case in.Secret != nil && in.Secret.NameFromField != nil:
+				fields = append(fields, in.Secret.NameFromField)
+			case in.ConfigMap != nil && in.ConfigMap.NameFromField != nil:
+				fields = append(fields, in.ConfigMap.NameFromField)
+			}
+		}
+	}
+	return fields
+}
+
+// deferredNameFromField returns the object field the binding reads its source
+// name from if the binding is deferred until the source resolves, or nil.
+func deferredNameFromField(b corev1alpha1.Binding) *corev1alpha1.SecretNameFromField {
+	if b.FailurePolicy != corev1alpha1.FailurePolicyDefer {
+		return nil
+	}
+	switch {
+	case b.From.Secret != nil:
+		return b.From.Secret.NameFromField
+	case b.From.ConfigMap != nil:
+		return b.From.ConfigMap.NameFromField
+	case b.From.Service != nil:
+		return b.From.Service.NameFromField
+	}
+	return nil
+}
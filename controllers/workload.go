@@ -0,0 +1,262 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	"github.com/oam-dev/trait-injector/pkg/injector"
+	"github.com/oam-dev/trait-injector/pkg/plugin"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// injectedAnnotation records on a workload the generation of each
+// ServiceBinding injected into it and the entries injected, as a JSON object
+// of injectedServiceBindings keyed by name.
+const injectedAnnotation = "core.oam.dev/servicebindings"
+
+// injectedServiceBinding records a ServiceBinding injected into a workload.
+type injectedServiceBinding struct {
+	Generation int64 `json:"generation"`
+	// Sources are the sources the bindings resolved to, by index, or empty
+	// for the bindings that did not resolve.
+	Sources  []string          `json:"sources,omitempty"`
+	Injected *plugin.Injection `json:"injected,omitempty"`
+}
+
+// sourcesChanged tells whether a binding resolved to another source than
+// recorded. Bindings that do not resolve anymore keep their recorded source.
+func sourcesChanged(recorded, sources []string) bool {
+	for i, s := range sources {
+		if i < len(recorded) && len(recorded[i]) != 0 && len(s) != 0 && recorded[i] != s {
+			return true
+		}
+	}
+	return false
+}
+
+// sharedInjections returns the entries recorded as injected for the
+// ServiceBindings but the named one.
+func sharedInjections(injected map[string]*injectedServiceBinding, name string) []*plugin.Injection {
+	names := make([]string, 0, len(injected))
+	for n := range injected {
+		if n != name {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	shared := make([]*plugin.Injection, 0, len(names))
+	for _, n := range names {
+		if in := injected[n].Injected; in != nil {
+			shared = append(shared, in)
+		}
+	}
+	return shared
+}
+
+func (r *ServiceBindingReconciler) getWorkload(ctx context.Context, namespace string, w *corev1alpha1.WorkloadReference) (*unstructured.Unstructured, error) {
+	gv, err := schema.ParseGroupVersion(w.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gv.WithKind(w.Kind))
+	err = r.Client.Get(ctx, client.ObjectKey{
+		Namespace: namespace,
+		Name:      w.Name,
+	}, u)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// isPermanent tells whether a request failed for a reason retrying it does
+// not fix, such as missing RBAC rules.
+func isPermanent(err error) bool {
+	return apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err) ||
+		apierrors.IsBadRequest(err) || apierrors.IsMethodNotSupported(err)
+}
+
+// patchWorkload applies the JSON patches to the workload, unless it has been
+// changed since it was read, as the patches are computed against array indices.
+func (r *ServiceBindingReconciler) patchWorkload(ctx context.Context, u *unstructured.Unstructured, patches []webhook.JSONPatchOp) error {
+	if rv := u.GetResourceVersion(); len(rv) != 0 {
+		patches = append([]webhook.JSONPatchOp{{
+			Operation: "test",
+			Path:      "/metadata/resourceVersion",
+			Value:     rv,
+		}}, patches...)
+	}
+	b, err := json.Marshal(patches)
+	if err != nil {
+		return err
+	}
+	return r.Client.Patch(ctx, u, client.ConstantPatch(types.JSONPatchType, b))
+}
+
+// newWorkloadRequest builds an admission request updating the workload to
+// itself, for the existing workload to be injected the same way as the
+// webhook does.
+func newWorkloadRequest(u *unstructured.Unstructured) (*admissionv1beta1.AdmissionRequest, error) {
+	b, err := u.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	gvk := u.GroupVersionKind()
+	return &admissionv1beta1.AdmissionRequest{
+		UID: u.GetUID(),
+		Kind: metav1.GroupVersionKind{
+			Group:   gvk.Group,
+			Version: gvk.Version,
+			Kind:    gvk.Kind,
+		},
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+		Operation: admissionv1beta1.Update,
+		Object:    runtime.RawExtension{Raw: b},
+		OldObject: runtime.RawExtension{Raw: b},
+	}, nil
+}
+
+// injectedServiceBindings returns the ServiceBindings recorded as injected
+// into the workload.
+func injectedServiceBindings(obj runtime.RawExtension) (map[string]*injectedServiceBinding, error) {
+	m, err := objectMeta(obj)
+	if err != nil {
+		return nil, err
+	}
+	injected := map[string]*injectedServiceBinding{}
+	v, ok := m.Annotations[injectedAnnotation]
+	if !ok {
+		return injected, nil
+	}
+	if err := json.Unmarshal([]byte(v), &injected); err != nil {
+		return nil, fmt.Errorf("parse annotation %s err: %w", injectedAnnotation, err)
+	}
+	return injected, nil
+}
+
+// recordInjected returns the patches recording the injected ServiceBindings
+// on the workload, if changed, removing the record if none is left.
+func recordInjected(obj runtime.RawExtension, injected map[string]*injectedServiceBinding) ([]webhook.JSONPatchOp, error) {
+	m, err := objectMeta(obj)
+	if err != nil {
+		return nil, err
+	}
+	annotationPath := "/metadata/annotations/" + plugin.EscapeJSONPointer(injectedAnnotation)
+
+	if len(injected) == 0 {
+		if _, ok := m.Annotations[injectedAnnotation]; !ok {
+			return nil, nil
+		}
+		return []webhook.JSONPatchOp{{
+			Operation: "remove",
+			Path:      annotationPath,
+		}}, nil
+	}
+
+	v, err := json.Marshal(injected)
+	if err != nil {
+		return nil, err
+	}
+	if m.Annotations[injectedAnnotation] == string(v) {
+		return nil, nil
+	}
+
+	var patches []webhook.JSONPatchOp
+	if m.Annotations == nil {
+		patches = append(patches, webhook.JSONPatchOp{
+			Operation: "add",
+			Path:      "/metadata/annotations",
+			Value:     map[string]string{},
+		})
+	}
+	patches = append(patches, webhook.JSONPatchOp{
+		Operation: "add",
+		Path:      annotationPath,
+		Value:     string(v),
+	})
+	return patches, nil
+}
+
+// selectedContainers returns the names of the workload's pod spec containers
+// matching the selector. The pod spec is read by the target injector of the
+// workload if it is not the one of spec.template.
+func (r *ServiceBindingReconciler) selectedContainers(req *admissionv1beta1.AdmissionRequest, obj runtime.RawExtension, w *corev1alpha1.WorkloadReference, s *corev1alpha1.ContainerSelector) ([]string, error) {
+	spec, err := r.readPodSpec(req, obj, w)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, c := range spec.Containers {
+		if s != nil {
+			if _, ok := injector.FindString(s.ByNames, c.Name); !ok {
+				continue
+			}
+		}
+		names = append(names, c.Name)
+	}
+	return names, nil
+}
+
+// readPodSpec returns the pod spec of the workload, as read by its target
+// injector if it implements plugin.PodSpecReader, or at spec.template.spec.
+func (r *ServiceBindingReconciler) readPodSpec(req *admissionv1beta1.AdmissionRequest, obj runtime.RawExtension, w *corev1alpha1.WorkloadReference) (*corev1.PodSpec, error) {
+	if psr, ok := r.Injectors.Find(req, w).(plugin.PodSpecReader); ok {
+		return psr.PodSpec(obj)
+	}
+	o := &struct {
+		Spec struct {
+			Template corev1.PodTemplateSpec `json:"template"`
+		} `json:"spec"`
+	}{}
+	if err := json.Unmarshal(obj.Raw, o); err != nil {
+		return nil, err
+	}
+	return &o.Spec.Template.Spec, nil
+}
+
+// setBindingNames sets the names of the sources resolved for a binding.
+func setBindingNames(bs *corev1alpha1.BindingStatus, values map[string]interface{}) {
+	bs.SecretName, _ = values["secret-name"].(string)
+	bs.ConfigMapName, _ = values["configmap-name"].(string)
+	bs.ServiceName, _ = values["service-name"].(string)
+	bs.PVCName, _ = values["pvc-name"].(string)
+}
+
+func objectMeta(obj runtime.RawExtension) (*metav1.ObjectMeta, error) {
+	o := &struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(obj.Raw, o); err != nil {
+		return nil, err
+	}
+	return &o.Metadata, nil
+}
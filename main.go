@@ -16,13 +16,16 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	"strings"
 
 	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
 	"github.com/oam-dev/trait-injector/controllers"
 	"github.com/oam-dev/trait-injector/pkg/injector"
 	"github.com/oam-dev/trait-injector/pkg/plugin"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -47,9 +50,15 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var targetsFile string
+	var disabledInjectors string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&targetsFile, "injection-targets", "",
+		"The YAML file listing the apiVersion, kind and podSpecPath of the workloads to inject besides the built-in ones.")
+	flag.StringVar(&disabledInjectors, "disable-target-injectors", "",
+		"The comma-separated names of the target injectors to disable, e.g. CronjobTargetInjector.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(func(o *zap.Options) {
@@ -69,14 +78,75 @@ func main() {
 		os.Exit(1)
 	}
 
-	// register all injectors
-	plugin.RegisterTargetInjectors(injector.Defaults()...)
+	// register all injectors, then the ones of the injection targets listed
+	// in the file and by InjectionTargets
+	injectors := plugin.NewRegistry()
+	if err := injectors.Register(injector.Defaults()...); err != nil {
+		setupLog.Error(err, "unable to register target injectors")
+		os.Exit(1)
+	}
+	var targets []corev1alpha1.InjectionTargetSpec
+	if len(targetsFile) != 0 {
+		targets, err = injector.ReadTargetsFile(targetsFile)
+		if err != nil {
+			setupLog.Error(err, "unable to read injection targets")
+			os.Exit(1)
+		}
+	}
+	itl := &corev1alpha1.InjectionTargetList{}
+	// the cache is not started yet
+	if err := mgr.GetAPIReader().List(context.Background(), itl); meta.IsNoMatchError(err) {
+		setupLog.Info("InjectionTarget CRD not installed")
+	} else if err != nil {
+		setupLog.Error(err, "unable to list injection targets")
+		os.Exit(1)
+	}
+	for _, t := range itl.Items {
+		targets = append(targets, t.Spec)
+	}
+	tis, err := injector.PodSpecTargetInjectors(targets)
+	if err != nil {
+		setupLog.Error(err, "invalid injection target")
+		os.Exit(1)
+	}
+	if err := injectors.Register(tis...); err != nil {
+		setupLog.Error(err, "unable to register target injectors")
+		os.Exit(1)
+	}
+	if len(disabledInjectors) != 0 {
+		if err := injectors.Disable(strings.Split(disabledInjectors, ",")...); err != nil {
+			setupLog.Error(err, "unable to disable target injectors")
+			os.Exit(1)
+		}
+	}
+	for _, ti := range injectors.Injectors() {
+		setupLog.Info("registered target injector", "name", ti.Name(), "enabled", injectors.Enabled(ti.Name()))
+	}
+	// the RBAC rules of the injector only cover the built-in kinds, unless
+	// extended for the kinds of the injection targets
+	for _, ti := range tis {
+		if !injectors.Enabled(ti.Name()) {
+			continue
+		}
+		for _, gvk := range ti.(plugin.KindLister).Kinds() {
+			err := controllers.CheckWorkloadAccess(context.Background(), mgr.GetClient(), mgr.GetRESTMapper(), gvk)
+			if meta.IsNoMatchError(err) {
+				setupLog.Info("injection target kind not installed", "apiVersion", gvk.GroupVersion().String(), "kind", gvk.Kind)
+				continue
+			}
+			if err != nil {
+				setupLog.Error(err, "unable to access injection target workloads", "apiVersion", gvk.GroupVersion().String(), "kind", gvk.Kind)
+				os.Exit(1)
+			}
+		}
+	}
 
 	r := &controllers.ServiceBindingReconciler{
-		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("ServiceBinding"),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("servicebinding"),
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("ServiceBinding"),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  mgr.GetEventRecorderFor("servicebinding"),
+		Injectors: injectors,
 	}
 	if err = (r).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ServiceBinding")
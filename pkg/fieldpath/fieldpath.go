@@ -0,0 +1,86 @@
+// Package fieldpath reads the values of object fields selected by
+// kubectl-style JSONPath expressions.
+package fieldpath
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// ErrNotFound is returned if the field path selects no value in the object.
+var ErrNotFound = errors.New("not found")
+
+// String returns the value of the field selected by the JSONPath expression
+// in the object, converting numbers and bools to strings. The expression is
+// either a template such as `{.status.outputs[?(@.name=="db")].value}` or a
+// bare path such as `.status.connections[0].secretRef.name`.
+//
+// An error wrapping ErrNotFound is returned if the path selects no value,
+// including an index beyond the end of an array. The path must select
+// exactly one value.
+func String(obj map[string]interface{}, path string) (string, error) {
+	j := jsonpath.New("fieldPath").AllowMissingKeys(true)
+	if err := j.Parse(relaxed(path)); err != nil {
+		return "", fmt.Errorf("parse fieldPath %s: %w", path, err)
+	}
+	results, err := j.FindResults(obj)
+	if err != nil {
+		// Arrays shorter than the index are missing the field, e.g. a status
+		// list that is not populated yet.
+		if strings.HasPrefix(err.Error(), "array index out of bounds") {
+			return "", fmt.Errorf("fieldPath %s %w: %s", path, ErrNotFound, err)
+		}
+		return "", fmt.Errorf("evaluate fieldPath %s: %w", path, err)
+	}
+
+	var values []reflect.Value
+	for _, r := range results {
+		values = append(values, r...)
+	}
+	switch len(values) {
+	case 0:
+		return "", fmt.Errorf("fieldPath %s %w", path, ErrNotFound)
+	case 1:
+	default:
+		return "", fmt.Errorf("fieldPath %s selects %d values, expected one", path, len(values))
+	}
+
+	v := values[0]
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", fmt.Errorf("fieldPath %s %w", path, ErrNotFound)
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("fieldPath %s selects a value of type %s, expected a string, number or bool", path, v.Kind())
+}
+
+// relaxed returns the JSONPath template of the expression, adding the braces
+// and leading dot of a bare path like kubectl does.
+func relaxed(path string) string {
+	path = strings.TrimSpace(path)
+	if strings.HasPrefix(path, "{") {
+		return path
+	}
+	if !strings.HasPrefix(path, ".") {
+		path = "." + path
+	}
+	return "{" + path + "}"
+}
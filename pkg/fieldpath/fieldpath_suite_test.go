@@ -0,0 +1,82 @@
+package fieldpath
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+func TestFieldPath(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FieldPath Suite")
+}
+
+var _ = Describe("FieldPath", func() {
+	var obj map[string]interface{}
+	BeforeEach(func() {
+		obj = map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(`{
+			"status": {
+				"secret": "db-secret",
+				"port": 5432,
+				"ratio": 0.5,
+				"ready": true,
+				"empty": null,
+				"connections": [
+					{"secretRef": {"name": "conn-0"}},
+					{"secretRef": {"name": "conn-1"}}
+				],
+				"outputs": [
+					{"name": "cache", "value": "cache-secret"},
+					{"name": "db", "value": "db-output"}
+				]
+			}
+		}`), &obj)).To(Succeed())
+	})
+
+	DescribeTable("should read the selected value",
+		func(path, expected string) {
+			v, err := String(obj, path)
+			Expect(err).To(BeNil())
+			Expect(v).To(Equal(expected))
+		},
+		Entry("bare path", ".status.secret", "db-secret"),
+		Entry("bare path without leading dot", "status.secret", "db-secret"),
+		Entry("template", "{.status.secret}", "db-secret"),
+		Entry("array index", ".status.connections[1].secretRef.name", "conn-1"),
+		Entry("negative array index", ".status.connections[-1].secretRef.name", "conn-1"),
+		Entry("filter", `{.status.outputs[?(@.name=="db")].value}`, "db-output"),
+		Entry("integer", ".status.port", "5432"),
+		Entry("float", ".status.ratio", "0.5"),
+		Entry("bool", ".status.ready", "true"),
+	)
+
+	DescribeTable("should report missing fields as not found",
+		func(path string) {
+			_, err := String(obj, path)
+			Expect(errors.Is(err, ErrNotFound)).To(BeTrue(), "%v", err)
+		},
+		Entry("missing key", ".status.missing"),
+		Entry("missing parent", ".spec.secret"),
+		Entry("array index out of bounds", ".status.connections[2].secretRef.name"),
+		Entry("filter without match", `{.status.outputs[?(@.name=="queue")].value}`),
+		Entry("null value", ".status.empty"),
+	)
+
+	DescribeTable("should fail on invalid paths or values",
+		func(path, message string) {
+			_, err := String(obj, path)
+			Expect(err).NotTo(BeNil())
+			Expect(errors.Is(err, ErrNotFound)).To(BeFalse())
+			Expect(err.Error()).To(ContainSubstring(message))
+		},
+		Entry("unparsable path", "{.status.connections[}", "parse fieldPath"),
+		Entry("object value", ".status.connections[0].secretRef", "expected a string, number or bool"),
+		Entry("several values", ".status.outputs[*].value", "selects 2 values"),
+		Entry("index of non array", ".status.secret[0]", "evaluate fieldPath"),
+	)
+})
@@ -0,0 +1,87 @@
+package injector
+
+import (
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	"github.com/oam-dev/trait-injector/pkg/plugin"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// cronJobTemplatePath is the JSON pointer of the pod template of the jobs
+// created by a CronJob. batch/v1 CronJobs share the schema of batch/v1beta1.
+const cronJobTemplatePath = "/spec/jobTemplate/spec/template"
+
+var _ plugin.TargetInjector = &CronjobTargetInjector{}
+var _ plugin.KindLister = &CronjobTargetInjector{}
+var _ plugin.PodSpecReader = &CronjobTargetInjector{}
+
+type CronjobTargetInjector struct {
+	Log logr.Logger
+}
+
+func newCronjobTargetInjector() *CronjobTargetInjector {
+	return &CronjobTargetInjector{
+		Log: ctrl.Log.WithName("targetInjectors").WithName("Cronjob"),
+	}
+}
+
+func (ti *CronjobTargetInjector) Name() string {
+	return "CronjobTargetInjector"
+}
+
+func (ti *CronjobTargetInjector) Match(req *admissionv1beta1.AdmissionRequest, w *corev1alpha1.WorkloadReference) bool {
+	k := req.Kind
+	if k.Group == "batch" && (k.Version == "v1" || k.Version == "v1beta1") && k.Kind == "CronJob" && req.Name == w.Name {
+		return true
+	}
+	return false
+}
+
+func (ti *CronjobTargetInjector) Kinds() []schema.GroupVersionKind {
+	return []schema.GroupVersionKind{
+		{Group: "batch", Version: "v1", Kind: "CronJob"},
+		{Group: "batch", Version: "v1beta1", Kind: "CronJob"},
+	}
+}
+
+func (ti *CronjobTargetInjector) Inject(ctx plugin.TargetContext, raw runtime.RawExtension) ([]webhook.JSONPatchOp, error) {
+	t, err := ti.podTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return makeInjectPatches(ti.Log, ctx, t)
+}
+
+func (ti *CronjobTargetInjector) Uninject(ctx plugin.TargetContext, raw runtime.RawExtension) ([]webhook.JSONPatchOp, error) {
+	t, err := ti.podTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return makeUninjectPatches(ti.Log, ctx, t)
+}
+
+// PodSpec returns the pod spec of the job template of the CronJob.
+func (ti *CronjobTargetInjector) PodSpec(raw runtime.RawExtension) (*corev1.PodSpec, error) {
+	t, err := ti.podTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return t.Spec, nil
+}
+
+// podTemplate returns the pod template of the job template of the CronJob.
+func (ti *CronjobTargetInjector) podTemplate(raw runtime.RawExtension) (*podTemplate, error) {
+	cronJob := &batchv1beta1.CronJob{}
+	if err := json.Unmarshal(raw.Raw, cronJob); err != nil {
+		return nil, err
+	}
+	return newPodTemplate(raw.Raw, cronJob.ObjectMeta, &cronJob.Spec.JobTemplate.Spec.Template, cronJobTemplatePath), nil
+}
@@ -0,0 +1,69 @@
+package injector
+
+import (
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	"github.com/oam-dev/trait-injector/pkg/plugin"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var _ plugin.TargetInjector = &DaemonsetTargetInjector{}
+var _ plugin.KindLister = &DaemonsetTargetInjector{}
+
+type DaemonsetTargetInjector struct {
+	Log logr.Logger
+}
+
+func newDaemonsetTargetInjector() *DaemonsetTargetInjector {
+	return &DaemonsetTargetInjector{
+		Log: ctrl.Log.WithName("targetInjectors").WithName("Daemonset"),
+	}
+}
+
+func (ti *DaemonsetTargetInjector) Name() string {
+	return "DaemonsetTargetInjector"
+}
+
+func (ti *DaemonsetTargetInjector) Match(req *admissionv1beta1.AdmissionRequest, w *corev1alpha1.WorkloadReference) bool {
+	k := req.Kind
+	if k.Group == "apps" && k.Version == "v1" && k.Kind == "DaemonSet" && req.Name == w.Name {
+		return true
+	}
+	return false
+}
+
+func (ti *DaemonsetTargetInjector) Kinds() []schema.GroupVersionKind {
+	return []schema.GroupVersionKind{{Group: "apps", Version: "v1", Kind: "DaemonSet"}}
+}
+
+func (ti *DaemonsetTargetInjector) Inject(ctx plugin.TargetContext, raw runtime.RawExtension) ([]webhook.JSONPatchOp, error) {
+	t, err := ti.podTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return makeInjectPatches(ti.Log, ctx, t)
+}
+
+func (ti *DaemonsetTargetInjector) Uninject(ctx plugin.TargetContext, raw runtime.RawExtension) ([]webhook.JSONPatchOp, error) {
+	t, err := ti.podTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return makeUninjectPatches(ti.Log, ctx, t)
+}
+
+// podTemplate returns the pod template of the DaemonSet.
+func (ti *DaemonsetTargetInjector) podTemplate(raw runtime.RawExtension) (*podTemplate, error) {
+	daemonSet := &appsv1.DaemonSet{}
+	if err := json.Unmarshal(raw.Raw, daemonSet); err != nil {
+		return nil, err
+	}
+	return newPodTemplate(raw.Raw, daemonSet.ObjectMeta, &daemonSet.Spec.Template, podTemplatePath), nil
+}
@@ -8,5 +8,9 @@ func Defaults() []plugin.TargetInjector {
 	return []plugin.TargetInjector{
 		newDeploymentTargetInjector(),
 		newStatefulsetTargetInjector(),
+		newDaemonsetTargetInjector(),
+		newReplicasetTargetInjector(),
+		newJobTargetInjector(),
+		newCronjobTargetInjector(),
 	}
 }
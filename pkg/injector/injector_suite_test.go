@@ -2,14 +2,20 @@ package injector
 
 import (
 	"encoding/json"
+	"io/ioutil"
+	"os"
 	"testing"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
 	"github.com/oam-dev/trait-injector/pkg/plugin"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -21,6 +27,25 @@ func TestInjector(t *testing.T) {
 	RunSpecs(t, "Injector Suite")
 }
 
+// patchTo matches the patches that, applied in order, patch the raw workload
+// into the same document as the expected ones.
+func patchTo(raw runtime.RawExtension, expected []webhook.JSONPatchOp) types.GomegaMatcher {
+	return WithTransform(func(patches []webhook.JSONPatchOp) string {
+		return string(applyPatches(raw.Raw, patches))
+	}, MatchJSON(applyPatches(raw.Raw, expected)))
+}
+
+// applyPatches returns the raw workload patched.
+func applyPatches(raw []byte, patches []webhook.JSONPatchOp) []byte {
+	p, err := json.Marshal(patches)
+	Expect(err).To(BeNil())
+	patch, err := jsonpatch.DecodePatch(p)
+	Expect(err).To(BeNil())
+	b, err := patch.Apply(raw)
+	Expect(err).To(BeNil())
+	return b
+}
+
 var _ = Describe("Injector", func() {
 	BeforeEach(func() {
 	})
@@ -30,6 +55,10 @@ var _ = Describe("Injector", func() {
 
 	di := newDeploymentTargetInjector()
 	si := newStatefulsetTargetInjector()
+	dsi := newDaemonsetTargetInjector()
+	rsi := newReplicasetTargetInjector()
+	ji := newJobTargetInjector()
+	ci := newCronjobTargetInjector()
 
 	Describe("workload injection", func() {
 		It("should inject secret to Deployment env", func() {
@@ -72,20 +101,16 @@ var _ = Describe("Injector", func() {
 
 			patches, err := di.Inject(ctx, raw)
 			Expect(err).To(BeNil())
-			Expect(patches).To(Equal([]webhook.JSONPatchOp{{
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
 				Operation: "add",
 				Path:      "/spec/template/spec/containers/0/envFrom",
-				Value:     []corev1.EnvFromSource{},
-			}, {
-				Operation: "add",
-				Path:      "/spec/template/spec/containers/0/envFrom/-",
-				Value: corev1.EnvFromSource{
+				Value: []corev1.EnvFromSource{{
 					SecretRef: &corev1.SecretEnvSource{
 						LocalObjectReference: corev1.LocalObjectReference{
 							Name: "test-secret",
 						},
 					},
-				},
+				}},
 			}}))
 		})
 		It("should inject secret to StatefulSet env", func() {
@@ -128,20 +153,16 @@ var _ = Describe("Injector", func() {
 
 			patches, err := si.Inject(ctx, raw)
 			Expect(err).To(BeNil())
-			Expect(patches).To(Equal([]webhook.JSONPatchOp{{
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
 				Operation: "add",
 				Path:      "/spec/template/spec/containers/0/envFrom",
-				Value:     []corev1.EnvFromSource{},
-			}, {
-				Operation: "add",
-				Path:      "/spec/template/spec/containers/0/envFrom/-",
-				Value: corev1.EnvFromSource{
+				Value: []corev1.EnvFromSource{{
 					SecretRef: &corev1.SecretEnvSource{
 						LocalObjectReference: corev1.LocalObjectReference{
 							Name: "test-secret",
 						},
 					},
-				},
+				}},
 			}}))
 		})
 		It("should inject secret to Deployment filePath", func() {
@@ -184,32 +205,24 @@ var _ = Describe("Injector", func() {
 
 			patches, err := di.Inject(ctx, raw)
 			Expect(err).To(BeNil())
-			Expect(patches).To(Equal([]webhook.JSONPatchOp{{
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
 				Operation: "add",
 				Path:      "/spec/template/spec/volumes",
-				Value:     []corev1.Volume{},
-			}, {
-				Operation: "add",
-				Path:      "/spec/template/spec/volumes/-",
-				Value: corev1.Volume{
+				Value: []corev1.Volume{{
 					Name: "secret-test-secret",
 					VolumeSource: corev1.VolumeSource{
 						Secret: &corev1.SecretVolumeSource{
 							SecretName: "test-secret",
 						},
 					},
-				},
+				}},
 			}, {
 				Operation: "add",
 				Path:      "/spec/template/spec/containers/0/volumeMounts",
-				Value:     []corev1.VolumeMount{},
-			}, {
-				Operation: "add",
-				Path:      "/spec/template/spec/containers/0/volumeMounts/-",
-				Value: corev1.VolumeMount{
+				Value: []corev1.VolumeMount{{
 					Name:      "secret-test-secret",
 					MountPath: "/test/path",
-				},
+				}},
 			}}))
 		})
 		It("should inject secret to StatefulSet filePath", func() {
@@ -252,32 +265,24 @@ var _ = Describe("Injector", func() {
 
 			patches, err := di.Inject(ctx, raw)
 			Expect(err).To(BeNil())
-			Expect(patches).To(Equal([]webhook.JSONPatchOp{{
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
 				Operation: "add",
 				Path:      "/spec/template/spec/volumes",
-				Value:     []corev1.Volume{},
-			}, {
-				Operation: "add",
-				Path:      "/spec/template/spec/volumes/-",
-				Value: corev1.Volume{
+				Value: []corev1.Volume{{
 					Name: "secret-test-secret",
 					VolumeSource: corev1.VolumeSource{
 						Secret: &corev1.SecretVolumeSource{
 							SecretName: "test-secret",
 						},
 					},
-				},
+				}},
 			}, {
 				Operation: "add",
 				Path:      "/spec/template/spec/containers/0/volumeMounts",
-				Value:     []corev1.VolumeMount{},
-			}, {
-				Operation: "add",
-				Path:      "/spec/template/spec/containers/0/volumeMounts/-",
-				Value: corev1.VolumeMount{
+				Value: []corev1.VolumeMount{{
 					Name:      "secret-test-secret",
 					MountPath: "/test/path",
-				},
+				}},
 			}}))
 		})
 		It("should inject pvc to Deployment filePath", func() {
@@ -320,32 +325,24 @@ var _ = Describe("Injector", func() {
 
 			patches, err := di.Inject(ctx, raw)
 			Expect(err).To(BeNil())
-			Expect(patches).To(Equal([]webhook.JSONPatchOp{{
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
 				Operation: "add",
 				Path:      "/spec/template/spec/volumes",
-				Value:     []corev1.Volume{},
-			}, {
-				Operation: "add",
-				Path:      "/spec/template/spec/volumes/-",
-				Value: corev1.Volume{
+				Value: []corev1.Volume{{
 					Name: "pvc-test-pvc",
 					VolumeSource: corev1.VolumeSource{
 						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
 							ClaimName: "test-pvc",
 						},
 					},
-				},
+				}},
 			}, {
 				Operation: "add",
 				Path:      "/spec/template/spec/containers/0/volumeMounts",
-				Value:     []corev1.VolumeMount{},
-			}, {
-				Operation: "add",
-				Path:      "/spec/template/spec/containers/0/volumeMounts/-",
-				Value: corev1.VolumeMount{
+				Value: []corev1.VolumeMount{{
 					Name:      "pvc-test-pvc",
 					MountPath: "/test/path",
-				},
+				}},
 			}}))
 		})
 		It("should inject pvc to StatefulSet filePath", func() {
@@ -388,144 +385,2193 @@ var _ = Describe("Injector", func() {
 
 			patches, err := di.Inject(ctx, raw)
 			Expect(err).To(BeNil())
-			Expect(patches).To(Equal([]webhook.JSONPatchOp{{
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
 				Operation: "add",
 				Path:      "/spec/template/spec/volumes",
-				Value:     []corev1.Volume{},
-			}, {
-				Operation: "add",
-				Path:      "/spec/template/spec/volumes/-",
-				Value: corev1.Volume{
+				Value: []corev1.Volume{{
 					Name: "pvc-test-pvc",
 					VolumeSource: corev1.VolumeSource{
 						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
 							ClaimName: "test-pvc",
 						},
 					},
-				},
+				}},
 			}, {
 				Operation: "add",
 				Path:      "/spec/template/spec/containers/0/volumeMounts",
-				Value:     []corev1.VolumeMount{},
-			}, {
-				Operation: "add",
-				Path:      "/spec/template/spec/containers/0/volumeMounts/-",
-				Value: corev1.VolumeMount{
+				Value: []corev1.VolumeMount{{
 					Name:      "pvc-test-pvc",
 					MountPath: "/test/path",
-				},
+				}},
 			}}))
 		})
-	})
-
-	Describe("request matching", func() {
-		It("should match Deployment injector", func() {
-			req := &admissionv1beta1.AdmissionRequest{
-				Kind: metav1.GroupVersionKind{
-					Group:   "apps",
-					Version: "v1",
-					Kind:    "Deployment",
+		It("should inject configmap to Deployment env", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						ConfigMap: &corev1alpha1.ConfigMapSource{
+							Name: "test-configmap",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						Env: true,
+					},
 				},
-				Name: "example",
-			}
-			wref := &corev1alpha1.WorkloadReference{
-				APIVersion: "apps/v1",
-				Kind:       "Deployment",
-				Name:       "example",
+				Values: map[string]interface{}{"configmap-name": "test-configmap"},
 			}
-
-			Expect(di.Match(req, wref)).To(Equal(true))
-		})
-
-		It("should not match Deployment injector if name mismatch", func() {
-			req := &admissionv1beta1.AdmissionRequest{
-				Kind: metav1.GroupVersionKind{
-					Group:   "apps",
-					Version: "v1",
-					Kind:    "Deployment",
+			d := &appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Deployment",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-deploy",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+								EnvFrom: []corev1.EnvFromSource{{
+									SecretRef: &corev1.SecretEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: "test-configmap",
+										},
+									},
+								}},
+							}},
+						},
+					},
 				},
-				Name: "unmatch-name",
 			}
-			wref := &corev1alpha1.WorkloadReference{
-				APIVersion: "apps/v1",
-				Kind:       "Deployment",
-				Name:       "example",
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
 			}
 
-			Expect(di.Match(req, wref)).To(Equal(false))
+			patches, err := di.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/envFrom/1",
+				Value: corev1.EnvFromSource{
+					ConfigMapRef: &corev1.ConfigMapEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: "test-configmap",
+						},
+					},
+				},
+			}}))
 		})
-
-		It("should not match Deployment injector if gvk mismatch", func() {
-			req := &admissionv1beta1.AdmissionRequest{
-				Kind: metav1.GroupVersionKind{
-					Group:   "apps",
-					Version: "v1",
-					Kind:    "UnmatchKind",
+		It("should inject configmap to StatefulSet filePath", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						ConfigMap: &corev1alpha1.ConfigMapSource{
+							Name: "test-configmap",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						FilePath: "/test/path",
+					},
 				},
-				Name: "example",
+				Values: map[string]interface{}{"configmap-name": "test-configmap"},
 			}
-			wref := &corev1alpha1.WorkloadReference{
-				APIVersion: "apps/v1",
-				Kind:       "Deployment",
-				Name:       "example",
-			}
-
-			Expect(di.Match(req, wref)).To(Equal(false))
-		})
-
-		It("should match StatefulSet injector", func() {
-			req := &admissionv1beta1.AdmissionRequest{
-				Kind: metav1.GroupVersionKind{
-					Group:   "apps",
-					Version: "v1",
-					Kind:    "StatefulSet",
+			d := &appsv1.StatefulSet{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "StatefulSet",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-statefulset",
+				},
+				Spec: appsv1.StatefulSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+							}},
+						},
+					},
 				},
-				Name: "example",
 			}
-			wref := &corev1alpha1.WorkloadReference{
-				APIVersion: "apps/v1",
-				Kind:       "StatefulSet",
-				Name:       "example",
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
 			}
 
-			Expect(si.Match(req, wref)).To(Equal(true))
+			patches, err := si.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/template/spec/volumes",
+				Value: []corev1.Volume{{
+					Name: "configmap-test-configmap",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-configmap",
+							},
+						},
+					},
+				}},
+			}, {
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/volumeMounts",
+				Value: []corev1.VolumeMount{{
+					Name:      "configmap-test-configmap",
+					MountPath: "/test/path",
+				}},
+			}}))
 		})
-
-		It("should not match StatefulSet injector if name mismatch", func() {
-			req := &admissionv1beta1.AdmissionRequest{
-				Kind: metav1.GroupVersionKind{
-					Group:   "apps",
-					Version: "v1",
-					Kind:    "StatefulSet",
+		It("should inject secret keys to Deployment env", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name: "test-secret",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						Env:    true,
+						Prefix: "DB_",
+						EnvMappings: []corev1alpha1.EnvMapping{{
+							Key:     "db.password",
+							EnvName: "DB_PASSWORD",
+						}, {
+							Key:      "TLS_CA",
+							Optional: true,
+						}},
+					},
 				},
-				Name: "unmatch-name",
+				Values: map[string]interface{}{"secret-name": "test-secret"},
 			}
-			wref := &corev1alpha1.WorkloadReference{
-				APIVersion: "apps/v1",
-				Kind:       "StatefulSet",
-				Name:       "example",
+			// injected before from another key
+			previous := makeEnvVar(corev1alpha1.EnvMapping{
+				Key:     "password",
+				EnvName: "DB_PASSWORD",
+			}, "test-secret", "")
+			ctx.Injected = &plugin.Injection{
+				Containers: map[string]*plugin.ContainerInjection{"test-container": {
+					Env: []corev1.EnvVar{previous},
+				}},
 			}
-
-			Expect(si.Match(req, wref)).To(Equal(false))
-		})
-
-		It("should not match StatefulSet injector if gvk mismatch", func() {
-			req := &admissionv1beta1.AdmissionRequest{
-				Kind: metav1.GroupVersionKind{
-					Group:   "apps",
-					Version: "v1",
-					Kind:    "UnmatchKind",
+			d := &appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Deployment",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-deploy",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+								Env:  []corev1.EnvVar{previous},
+							}},
+						},
+					},
 				},
-				Name: "example",
 			}
-			wref := &corev1alpha1.WorkloadReference{
-				APIVersion: "apps/v1",
-				Kind:       "StatefulSet",
-				Name:       "example",
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
 			}
 
-			Expect(si.Match(req, wref)).To(Equal(false))
+			optional := true
+			patches, err := di.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/envFrom",
+				Value: []corev1.EnvFromSource{{
+					Prefix: "DB_",
+					SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: "test-secret",
+						},
+					},
+				}},
+			}, {
+				Operation: "replace",
+				Path:      "/spec/template/spec/containers/0/env/0/valueFrom/secretKeyRef/key",
+				Value:     "db.password",
+			}, {
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/env/1",
+				Value: corev1.EnvVar{
+					Name: "TLS_CA",
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key:      "TLS_CA",
+							Optional: &optional,
+						},
+					},
+				},
+			}}))
+		})
+		It("should not replace an env var the workload sets", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name: "test-secret",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						EnvMappings: []corev1alpha1.EnvMapping{{
+							Key:     "password",
+							EnvName: "DB_PASSWORD",
+						}},
+					},
+				},
+				Values:   map[string]interface{}{"secret-name": "test-secret"},
+				Injected: &plugin.Injection{},
+			}
+			env := []corev1.EnvVar{{
+				Name:  "DB_PASSWORD",
+				Value: "default",
+			}}
+			b, err := json.Marshal(&appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+								Env:  env,
+							}},
+						},
+					},
+				},
+			})
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
+			}
+
+			_, err = di.Inject(ctx, raw)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("env var DB_PASSWORD of container test-container is set by the workload"))
+
+			ctx.Shared = []*plugin.Injection{{
+				Containers: map[string]*plugin.ContainerInjection{"test-container": {
+					Env: env,
+				}},
+			}}
+			_, err = di.Inject(ctx, raw)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("is set by another ServiceBinding"))
+			Expect(ctx.Injected).To(Equal(&plugin.Injection{}))
+		})
+		It("should inject secret under the binding root of Deployment", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name: "test-secret",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						BindingRoot: &corev1alpha1.BindingRootTarget{
+							Name: "db",
+							Type: "postgresql",
+						},
+					},
+				},
+				Values: map[string]interface{}{"secret-name": "test-binding-binding-0"},
+			}
+			d := &appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Deployment",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-deploy",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+							}, {
+								Name: "test-sidecar",
+								Env: []corev1.EnvVar{{
+									Name:  "SERVICE_BINDING_ROOT",
+									Value: "/var/bindings",
+								}},
+							}},
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
+			}
+
+			patches, err := di.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/env",
+				Value: []corev1.EnvVar{{
+					Name:  "SERVICE_BINDING_ROOT",
+					Value: "/bindings",
+				}},
+			}, {
+				Operation: "add",
+				Path:      "/spec/template/spec/volumes",
+				Value: []corev1.Volume{{
+					Name: "secret-test-binding-binding-0",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName: "test-binding-binding-0",
+						},
+					},
+				}},
+			}, {
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/volumeMounts",
+				Value: []corev1.VolumeMount{{
+					Name:      "secret-test-binding-binding-0",
+					MountPath: "/bindings/db",
+				}},
+			}, {
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/1/volumeMounts",
+				Value: []corev1.VolumeMount{{
+					Name:      "secret-test-binding-binding-0",
+					MountPath: "/var/bindings/db",
+				}},
+			}}))
+		})
+
+		It("should inject secret to DaemonSet env", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name: "test-secret",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						Env: true,
+					},
+				},
+				Values: map[string]interface{}{"secret-name": "test-secret"},
+			}
+			d := &appsv1.DaemonSet{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "DaemonSet",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-daemonset",
+				},
+				Spec: appsv1.DaemonSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+							}},
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
+			}
+
+			patches, err := dsi.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/envFrom",
+				Value: []corev1.EnvFromSource{{
+					SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: "test-secret",
+						},
+					},
+				}},
+			}}))
+		})
+
+		It("should inject configmap keys to ReplicaSet env", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						ConfigMap: &corev1alpha1.ConfigMapSource{
+							Name: "test-configmap",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						EnvMappings: []corev1alpha1.EnvMapping{{
+							Key:     "url",
+							EnvName: "APP_URL",
+						}},
+					},
+				},
+				Values: map[string]interface{}{"configmap-name": "test-configmap"},
+			}
+			d := &appsv1.ReplicaSet{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "ReplicaSet",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-replicaset",
+				},
+				Spec: appsv1.ReplicaSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+							}},
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
+			}
+
+			patches, err := rsi.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/env",
+				Value: []corev1.EnvVar{{
+					Name: "APP_URL",
+					ValueFrom: &corev1.EnvVarSource{
+						ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-configmap",
+							},
+							Key: "url",
+						},
+					},
+				}},
+			}}))
+		})
+
+		It("should inject secret to Job filePath", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name: "test-secret",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						FilePath: "/test/path",
+					},
+				},
+				Values: map[string]interface{}{"secret-name": "test-secret"},
+			}
+			d := &batchv1.Job{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Job",
+					APIVersion: "batch/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-job",
+				},
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+							}},
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
+			}
+
+			patches, err := ji.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/template/spec/volumes",
+				Value: []corev1.Volume{{
+					Name: "secret-test-secret",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName: "test-secret",
+						},
+					},
+				}},
+			}, {
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/volumeMounts",
+				Value: []corev1.VolumeMount{{
+					Name:      "secret-test-secret",
+					MountPath: "/test/path",
+				}},
+			}}))
+		})
+
+		It("should annotate the pod template of Job without metadata", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name:            "test-secret",
+							RolloutOnChange: true,
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						Env: true,
+					},
+				},
+				Values:   map[string]interface{}{"secret-name": "test-secret", "secret-checksum": "abc"},
+				Injected: &plugin.Injection{},
+			}
+			raw := runtime.RawExtension{
+				Raw: []byte(`{
+					"apiVersion": "batch/v1",
+					"kind": "Job",
+					"metadata": {"name": "test-job"},
+					"spec": {"template": {"spec": {
+						"containers": [{"name": "test-container"}],
+						"restartPolicy": "Never"
+					}}}
+				}`),
+			}
+
+			patches, err := ji.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			injected := applyPatches(raw.Raw, patches)
+			Expect(injected).To(MatchJSON(`{
+				"apiVersion": "batch/v1",
+				"kind": "Job",
+				"metadata": {"name": "test-job"},
+				"spec": {"template": {
+					"metadata": {"annotations": {"secret.checksum.core.oam.dev/test-secret": "abc"}},
+					"spec": {
+						"containers": [{"name": "test-container", "envFrom": [{"secretRef": {"name": "test-secret"}}]}],
+						"restartPolicy": "Never"
+					}
+				}}
+			}`))
+
+			patches, err = ji.Uninject(ctx, runtime.RawExtension{Raw: injected})
+			Expect(err).To(BeNil())
+			Expect(applyPatches(injected, patches)).To(MatchJSON(`{
+				"apiVersion": "batch/v1",
+				"kind": "Job",
+				"metadata": {"name": "test-job"},
+				"spec": {"template": {
+					"metadata": {},
+					"spec": {
+						"containers": [{"name": "test-container"}],
+						"restartPolicy": "Never"
+					}
+				}}
+			}`))
+		})
+
+		It("should inject the pod template of every workload kind alike", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name:            "test-secret",
+							RolloutOnChange: true,
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						Env:      true,
+						FilePath: "/test/path",
+						EnvMappings: []corev1alpha1.EnvMapping{{
+							Key: "password",
+						}},
+					},
+					ContainerSelector: &corev1alpha1.ContainerSelector{
+						ByNames: []string{"test-container"},
+					},
+				},
+				Values: map[string]interface{}{"secret-name": "test-secret", "secret-checksum": "abc"},
+			}
+			template := corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "test-sidecar",
+					}, {
+						Name: "test-container",
+					}},
+				},
+			}
+			marshal := func(o interface{}) runtime.RawExtension {
+				b, err := json.Marshal(o)
+				Expect(err).To(BeNil())
+				return runtime.RawExtension{
+					Raw: b,
+				}
+			}
+
+			expected, err := di.Inject(ctx, marshal(&appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{Template: template},
+			}))
+			Expect(err).To(BeNil())
+			Expect(expected).To(HaveLen(5))
+			for _, c := range []struct {
+				injector plugin.TargetInjector
+				workload interface{}
+			}{
+				{si, &appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Template: template}}},
+				{dsi, &appsv1.DaemonSet{Spec: appsv1.DaemonSetSpec{Template: template}}},
+				{rsi, &appsv1.ReplicaSet{Spec: appsv1.ReplicaSetSpec{Template: template}}},
+				{ji, &batchv1.Job{Spec: batchv1.JobSpec{Template: template}}},
+			} {
+				patches, err := c.injector.Inject(ctx, marshal(c.workload))
+				Expect(err).To(BeNil())
+				Expect(patches).To(patchTo(marshal(c.workload), expected), c.injector.Name())
+			}
+
+			raw := marshal(&batchv1beta1.CronJob{
+				Spec: batchv1beta1.CronJobSpec{
+					JobTemplate: batchv1beta1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{Template: template},
+					},
+				},
+			})
+			patches, err := ci.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			for i := range expected {
+				expected[i].Path = "/spec/jobTemplate" + expected[i].Path
+			}
+			Expect(patches).To(patchTo(raw, expected))
+		})
+
+		It("should inject secret to the job template of CronJob", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name:            "test-secret",
+							RolloutOnChange: true,
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						Env:      true,
+						FilePath: "/test/path",
+					},
+				},
+				Values:   map[string]interface{}{"secret-name": "test-secret", "secret-checksum": "abc"},
+				Injected: &plugin.Injection{},
+			}
+			d := &batchv1beta1.CronJob{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "CronJob",
+					APIVersion: "batch/v1beta1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-cronjob",
+				},
+				Spec: batchv1beta1.CronJobSpec{
+					Schedule: "@hourly",
+					JobTemplate: batchv1beta1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									Containers: []corev1.Container{{
+										Name:    "test-container",
+										EnvFrom: []corev1.EnvFromSource{},
+										VolumeMounts: []corev1.VolumeMount{{
+											Name:      "cache",
+											MountPath: "/cache",
+										}},
+									}},
+									Volumes: []corev1.Volume{{
+										Name: "cache",
+										VolumeSource: corev1.VolumeSource{
+											EmptyDir: &corev1.EmptyDirVolumeSource{},
+										},
+									}},
+								},
+							},
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
+			}
+
+			patches, err := ci.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/jobTemplate/spec/template/spec/containers/0/envFrom",
+				Value: []corev1.EnvFromSource{{
+					SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: "test-secret",
+						},
+					},
+				}},
+			}, {
+				Operation: "add",
+				Path:      "/spec/jobTemplate/spec/template/spec/volumes/1",
+				Value: corev1.Volume{
+					Name: "secret-test-secret",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName: "test-secret",
+						},
+					},
+				},
+			}, {
+				Operation: "add",
+				Path:      "/spec/jobTemplate/spec/template/spec/containers/0/volumeMounts/1",
+				Value: corev1.VolumeMount{
+					Name:      "secret-test-secret",
+					MountPath: "/test/path",
+				},
+			}, {
+				Operation: "add",
+				Path:      "/spec/jobTemplate/spec/template/metadata/annotations",
+				Value:     map[string]string{"secret.checksum.core.oam.dev/test-secret": "abc"},
+			}}))
+
+			p, err := json.Marshal(patches)
+			Expect(err).To(BeNil())
+			patch, err := jsonpatch.DecodePatch(p)
+			Expect(err).To(BeNil())
+			raw.Raw, err = patch.Apply(raw.Raw)
+			Expect(err).To(BeNil())
+
+			patches, err = ci.Uninject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "remove",
+				Path:      "/spec/jobTemplate/spec/template/spec/containers/0/envFrom",
+			}, {
+				Operation: "remove",
+				Path:      "/spec/jobTemplate/spec/template/spec/containers/0/volumeMounts/1",
+			}, {
+				Operation: "remove",
+				Path:      "/spec/jobTemplate/spec/template/spec/volumes/1",
+			}, {
+				Operation: "remove",
+				Path:      "/spec/jobTemplate/spec/template/metadata/annotations",
+			}}))
+
+			p, err = json.Marshal(patches)
+			Expect(err).To(BeNil())
+			patch, err = jsonpatch.DecodePatch(p)
+			Expect(err).To(BeNil())
+			uninjected, err := patch.Apply(raw.Raw)
+			Expect(err).To(BeNil())
+			Expect(uninjected).To(MatchJSON(b))
+		})
+	})
+
+	Describe("binding composition", func() {
+		It("should apply the patches of several bindings one after another", func() {
+			secretInjected, configMapInjected := &plugin.Injection{}, &plugin.Injection{}
+			secretCtx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name: "db-secret",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						Env:      true,
+						FilePath: "/etc/db",
+					},
+				},
+				Values:   map[string]interface{}{"secret-name": "db-secret"},
+				Injected: secretInjected,
+				Shared:   []*plugin.Injection{configMapInjected},
+			}
+			configMapCtx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						ConfigMap: &corev1alpha1.ConfigMapSource{
+							Name: "app-config",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						Env:      true,
+						FilePath: "/etc/app",
+					},
+				},
+				Values:   map[string]interface{}{"configmap-name": "app-config"},
+				Injected: configMapInjected,
+				Shared:   []*plugin.Injection{secretInjected},
+			}
+			d := &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+							}},
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
+			}
+			apply := func(patches []webhook.JSONPatchOp, err error) {
+				Expect(err).To(BeNil())
+				p, err := json.Marshal(patches)
+				Expect(err).To(BeNil())
+				patch, err := jsonpatch.DecodePatch(p)
+				Expect(err).To(BeNil())
+				raw.Raw, err = patch.Apply(raw.Raw)
+				Expect(err).To(BeNil())
+			}
+
+			apply(di.Inject(secretCtx, raw))
+			apply(di.Inject(configMapCtx, raw))
+			apply(di.Uninject(secretCtx, raw))
+
+			injected := &appsv1.Deployment{}
+			Expect(json.Unmarshal(raw.Raw, injected)).To(Succeed())
+			Expect(injected.Spec.Template.Spec).To(Equal(corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:    "test-container",
+					EnvFrom: []corev1.EnvFromSource{makeEnvFromSource("", "app-config", "")},
+					VolumeMounts: []corev1.VolumeMount{{
+						Name:      "configmap-app-config",
+						MountPath: "/etc/app",
+					}},
+				}},
+				Volumes: []corev1.Volume{{
+					Name: "configmap-app-config",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "app-config",
+							},
+						},
+					},
+				}},
+			}))
+		})
+	})
+
+	Describe("workload reinjection", func() {
+		ctx := plugin.TargetContext{
+			Binding: &corev1alpha1.Binding{
+				From: corev1alpha1.DataSource{
+					Secret: &corev1alpha1.SecretSource{
+						Name: "test-secret",
+					},
+				},
+				To: corev1alpha1.DataTarget{
+					Env:      true,
+					FilePath: "/test/path",
+				},
+			},
+			Values: map[string]interface{}{"secret-name": "test-secret"},
+		}
+		newDeployment := func(volumeSource corev1.VolumeSource) runtime.RawExtension {
+			d := &appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Deployment",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-deploy",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+								EnvFrom: []corev1.EnvFromSource{{
+									SecretRef: &corev1.SecretEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: "test-secret",
+										},
+									},
+								}},
+								VolumeMounts: []corev1.VolumeMount{{
+									Name:      "secret-test-secret",
+									MountPath: "/test/path",
+								}},
+							}},
+							Volumes: []corev1.Volume{{
+								Name:         "secret-test-secret",
+								VolumeSource: volumeSource,
+							}},
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			return runtime.RawExtension{
+				Raw: b,
+			}
+		}
+
+		It("should skip data already injected", func() {
+			mode := int32(420)
+			raw := newDeployment(corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  "test-secret",
+					DefaultMode: &mode,
+				},
+			})
+
+			patches, err := di.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(BeEmpty())
+		})
+
+		It("should replace a volume whose source changed", func() {
+			raw := newDeployment(corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			})
+			ctx := ctx
+			ctx.Injected = &plugin.Injection{
+				Volumes: []string{"secret-test-secret"},
+			}
+
+			patches, err := di.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "remove",
+				Path:      "/spec/template/spec/volumes/0/emptyDir",
+			}, {
+				Operation: "add",
+				Path:      "/spec/template/spec/volumes/0/secret",
+				Value: &corev1.SecretVolumeSource{
+					SecretName: "test-secret",
+				},
+			}}))
+		})
+
+		It("should annotate the pod template with the secret checksum", func() {
+			raw := newDeployment(corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: "test-secret",
+				},
+			})
+			rolloutCtx := plugin.TargetContext{
+				Binding: ctx.Binding,
+				Values: map[string]interface{}{
+					"secret-name":     "test-secret",
+					"secret-checksum": "abc",
+				},
+				Injected: &plugin.Injection{},
+			}
+
+			patches, err := di.Inject(rolloutCtx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/template/metadata/annotations",
+				Value:     map[string]string{"secret.checksum.core.oam.dev/test-secret": "abc"},
+			}}))
+
+			d := &appsv1.Deployment{}
+			Expect(json.Unmarshal(raw.Raw, d)).To(Succeed())
+			d.Spec.Template.Annotations = map[string]string{"secret.checksum.core.oam.dev/test-secret": "abc"}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw = runtime.RawExtension{Raw: b}
+
+			patches, err = di.Inject(rolloutCtx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(BeEmpty())
+
+			rolloutCtx.Values["secret-checksum"] = "def"
+			patches, err = di.Inject(rolloutCtx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "replace",
+				Path:      "/spec/template/metadata/annotations/secret.checksum.core.oam.dev~1test-secret",
+				Value:     "def",
+			}}))
+
+			patches, err = di.Uninject(rolloutCtx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(ContainElement(webhook.JSONPatchOp{
+				Operation: "remove",
+				Path:      "/spec/template/metadata/annotations",
+			}))
+		})
+	})
+
+	Describe("projected volumes", func() {
+		projectedName := makeProjectedVolumeName("/etc/app")
+		secretVolume := corev1.Volume{
+			Name: "secret-app-secret",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: "app-secret",
+				},
+			},
+		}
+		newDeployment := func(volumes []corev1.Volume, mounts []corev1.VolumeMount) runtime.RawExtension {
+			d := &appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Deployment",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-deploy",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name:         "test-container",
+								VolumeMounts: mounts,
+							}},
+							Volumes: volumes,
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			return runtime.RawExtension{
+				Raw: b,
+			}
+		}
+		configMapCtx := plugin.TargetContext{
+			Binding: &corev1alpha1.Binding{
+				From: corev1alpha1.DataSource{
+					ConfigMap: &corev1alpha1.ConfigMapSource{
+						Name: "app-config",
+					},
+				},
+				To: corev1alpha1.DataTarget{
+					FilePath: "/etc/app",
+					Items: []corev1.KeyToPath{{
+						Key:  "app.yaml",
+						Path: "app.yaml",
+					}},
+					SubDirectory: "config",
+				},
+			},
+			Values: map[string]interface{}{"configmap-name": "app-config"},
+		}
+		configMapProjection := corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: "app-config",
+				},
+				Items: []corev1.KeyToPath{{
+					Key:  "app.yaml",
+					Path: "config/app.yaml",
+				}},
+			},
+		}
+		secretProjection := corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: "app-secret",
+				},
+			},
+		}
+
+		It("should merge a source into the volume mounted at the same path", func() {
+			raw := newDeployment([]corev1.Volume{secretVolume}, []corev1.VolumeMount{{
+				Name:      "secret-app-secret",
+				MountPath: "/etc/app",
+			}})
+
+			patches, err := di.Inject(configMapCtx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "replace",
+				Path:      "/spec/template/spec/volumes/0/name",
+				Value:     projectedName,
+			}, {
+				Operation: "remove",
+				Path:      "/spec/template/spec/volumes/0/secret",
+			}, {
+				Operation: "add",
+				Path:      "/spec/template/spec/volumes/0/projected",
+				Value: &corev1.ProjectedVolumeSource{
+					Sources: []corev1.VolumeProjection{secretProjection, configMapProjection},
+				},
+			}, {
+				Operation: "replace",
+				Path:      "/spec/template/spec/containers/0/volumeMounts/0/name",
+				Value:     projectedName,
+			}}))
+		})
+
+		It("should add a source to the projected volume of the path", func() {
+			raw := newDeployment([]corev1.Volume{{
+				Name: projectedName,
+				VolumeSource: corev1.VolumeSource{
+					Projected: &corev1.ProjectedVolumeSource{
+						Sources: []corev1.VolumeProjection{secretProjection},
+					},
+				},
+			}}, []corev1.VolumeMount{{
+				Name:      projectedName,
+				MountPath: "/etc/app",
+			}})
+
+			patches, err := si.Inject(plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						ServiceAccountToken: &corev1alpha1.ServiceAccountTokenSource{
+							Audience: "vault",
+							Path:     "token",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						FilePath: "/etc/app",
+					},
+				},
+				Values: map[string]interface{}{},
+			}, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/template/spec/volumes/0/projected/sources/1",
+				Value: corev1.VolumeProjection{
+					ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+						Audience: "vault",
+						Path:     "token",
+					},
+				},
+			}}))
+		})
+
+		It("should not merge into a volume that cannot be projected", func() {
+			raw := newDeployment([]corev1.Volume{{
+				Name: "cache",
+				VolumeSource: corev1.VolumeSource{
+					EmptyDir: &corev1.EmptyDirVolumeSource{},
+				},
+			}}, []corev1.VolumeMount{{
+				Name:      "cache",
+				MountPath: "/etc/app",
+			}})
+
+			_, err := di.Inject(configMapCtx, raw)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("is taken by volume cache"))
+		})
+
+		It("should remove a source from the projected volume of the path", func() {
+			volumes := []corev1.Volume{{
+				Name: projectedName,
+				VolumeSource: corev1.VolumeSource{
+					Projected: &corev1.ProjectedVolumeSource{
+						Sources: []corev1.VolumeProjection{secretProjection, configMapProjection},
+					},
+				},
+			}}
+			mounts := []corev1.VolumeMount{{
+				Name:      projectedName,
+				MountPath: "/etc/app",
+			}}
+
+			configMapCtx := configMapCtx
+			configMapCtx.Injected = &plugin.Injection{
+				Projections: map[string][]corev1.VolumeProjection{projectedName: {configMapProjection}},
+			}
+			raw := newDeployment(volumes, mounts)
+			patches, err := di.Uninject(configMapCtx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "remove",
+				Path:      "/spec/template/spec/volumes/0/projected/sources/1",
+			}}))
+
+			secretCtx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name: "app-secret",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						FilePath: "/etc/app",
+					},
+				},
+				Values: map[string]interface{}{"secret-name": "app-secret"},
+				Injected: &plugin.Injection{
+					Projections: map[string][]corev1.VolumeProjection{projectedName: {secretProjection}},
+				},
+			}
+			volumes[0].Projected.Sources = volumes[0].Projected.Sources[:1]
+			raw = newDeployment(volumes, mounts)
+			patches, err = di.Uninject(secretCtx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "remove",
+				Path:      "/spec/template/spec/containers/0/volumeMounts",
+			}, {
+				Operation: "remove",
+				Path:      "/spec/template/spec/volumes",
+			}}))
+		})
+	})
+
+	Describe("file options", func() {
+		mode := int32(0400)
+		ctx := plugin.TargetContext{
+			Binding: &corev1alpha1.Binding{
+				From: corev1alpha1.DataSource{
+					Secret: &corev1alpha1.SecretSource{
+						Name: "tls",
+					},
+				},
+				To: corev1alpha1.DataTarget{
+					FilePath: "/etc/tls/tls.key",
+					Items: []corev1.KeyToPath{{
+						Key:  "tls.key",
+						Path: "tls.key",
+					}},
+					SubPath:     "tls.key",
+					ReadOnly:    true,
+					DefaultMode: &mode,
+				},
+			},
+			Values: map[string]interface{}{"secret-name": "tls"},
+		}
+		volume := corev1.Volume{
+			Name: "secret-tls",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: "tls",
+					Items: []corev1.KeyToPath{{
+						Key:  "tls.key",
+						Path: "tls.key",
+					}},
+					DefaultMode: &mode,
+				},
+			},
+		}
+		mount := corev1.VolumeMount{
+			Name:      "secret-tls",
+			MountPath: "/etc/tls/tls.key",
+			SubPath:   "tls.key",
+			ReadOnly:  true,
+		}
+		newDeployment := func(volumes []corev1.Volume, mounts []corev1.VolumeMount) runtime.RawExtension {
+			d := &appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Deployment",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-deploy",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name:         "test-container",
+								VolumeMounts: mounts,
+							}},
+							Volumes: volumes,
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			return runtime.RawExtension{
+				Raw: b,
+			}
+		}
+
+		It("should mount the selected key as a read-only file", func() {
+			raw := newDeployment(nil, nil)
+			patches, err := di.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/template/spec/volumes",
+				Value:     []corev1.Volume{volume},
+			}, {
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/volumeMounts",
+				Value:     []corev1.VolumeMount{mount},
+			}}))
+		})
+
+		It("should replace the volume and mount injected with other options", func() {
+			old := *volume.Secret
+			old.Items = nil
+			defaultMode := int32(0644)
+			old.DefaultMode = &defaultMode
+			raw := newDeployment([]corev1.Volume{{
+				Name: "secret-tls",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &old,
+				},
+			}}, []corev1.VolumeMount{{
+				Name:      "secret-tls",
+				MountPath: "/etc/tls/tls.key",
+			}})
+			ctx := ctx
+			ctx.Injected = &plugin.Injection{
+				Containers: map[string]*plugin.ContainerInjection{"test-container": {
+					VolumeMounts: []corev1.VolumeMount{{
+						Name:      "secret-tls",
+						MountPath: "/etc/tls/tls.key",
+					}},
+				}},
+				Volumes: []string{"secret-tls"},
+			}
+			patches, err := si.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/volumeMounts/0/subPath",
+				Value:     mount.SubPath,
+			}, {
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/volumeMounts/0/readOnly",
+				Value:     true,
+			}, {
+				Operation: "add",
+				Path:      "/spec/template/spec/volumes/0/secret/items",
+				Value:     volume.Secret.Items,
+			}, {
+				Operation: "replace",
+				Path:      "/spec/template/spec/volumes/0/secret/defaultMode",
+				Value:     volume.Secret.DefaultMode,
+			}}))
+
+			patches, err = si.Inject(ctx, newDeployment([]corev1.Volume{volume}, []corev1.VolumeMount{mount}))
+			Expect(err).To(BeNil())
+			Expect(patches).To(BeEmpty())
+		})
+
+		It("should not mount a sub-path at a path taken by another volume", func() {
+			_, err := di.Inject(ctx, newDeployment([]corev1.Volume{{
+				Name: "secret-app-secret",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: "app-secret",
+					},
+				},
+			}}, []corev1.VolumeMount{{
+				Name:      "secret-app-secret",
+				MountPath: "/etc/tls/tls.key",
+			}}))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("is taken by volume secret-app-secret"))
+		})
+	})
+
+	Describe("pod spec path injection", func() {
+		ctx := plugin.TargetContext{
+			Binding: &corev1alpha1.Binding{
+				From: corev1alpha1.DataSource{
+					Secret: &corev1alpha1.SecretSource{
+						Name:            "test-secret",
+						RolloutOnChange: true,
+					},
+				},
+				To: corev1alpha1.DataTarget{
+					Env:      true,
+					FilePath: "/test/path",
+				},
+			},
+			Values: map[string]interface{}{"secret-name": "test-secret", "secret-checksum": "abc"},
+		}
+		newWorkload := func(podSpecField string) runtime.RawExtension {
+			b, err := json.Marshal(map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "App",
+				"metadata": map[string]interface{}{
+					"name": "test-app",
+				},
+				"spec": map[string]interface{}{
+					podSpecField: map[string]interface{}{
+						"containers": []interface{}{map[string]interface{}{
+							"name": "test-container",
+						}},
+					},
+				},
+			})
+			Expect(err).To(BeNil())
+			return runtime.RawExtension{
+				Raw: b,
+			}
+		}
+
+		It("should inject secret into the pod spec at the path", func() {
+			ti, err := NewPodSpecTargetInjector(corev1alpha1.InjectionTargetSpec{
+				APIVersion:  "example.com/v1",
+				Kind:        "App",
+				PodSpecPath: "spec.podSpec",
+			})
+			Expect(err).To(BeNil())
+
+			raw := newWorkload("podSpec")
+			patches, err := ti.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/podSpec/containers/0/envFrom",
+				Value: []corev1.EnvFromSource{{
+					SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: "test-secret",
+						},
+					},
+				}},
+			}, {
+				Operation: "add",
+				Path:      "/spec/podSpec/volumes",
+				Value: []corev1.Volume{{
+					Name: "secret-test-secret",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName: "test-secret",
+						},
+					},
+				}},
+			}, {
+				Operation: "add",
+				Path:      "/spec/podSpec/containers/0/volumeMounts",
+				Value: []corev1.VolumeMount{{
+					Name:      "secret-test-secret",
+					MountPath: "/test/path",
+				}},
+			}}))
+
+			spec, err := ti.PodSpec(newWorkload("podSpec"))
+			Expect(err).To(BeNil())
+			Expect(spec.Containers[0].Name).To(Equal("test-container"))
+		})
+
+		It("should annotate the pod template of the pod spec with the secret checksum", func() {
+			ti, err := NewPodSpecTargetInjector(corev1alpha1.InjectionTargetSpec{
+				APIVersion:  "example.com/v1",
+				Kind:        "App",
+				PodSpecPath: "spec.template.spec",
+			})
+			Expect(err).To(BeNil())
+
+			patches, err := ti.Inject(plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: ctx.Binding.From,
+					To: corev1alpha1.DataTarget{
+						Env: true,
+					},
+				},
+				Values: ctx.Values,
+			}, newWorkload("template"))
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("pod spec of test-app not found at spec.template.spec"))
+			Expect(patches).To(BeNil())
+
+			b, err := json.Marshal(map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": "test-app",
+				},
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"annotations": map[string]interface{}{
+								"secret.checksum.core.oam.dev/test-secret": "old",
+							},
+						},
+						"spec": map[string]interface{}{
+							"containers": []interface{}{map[string]interface{}{
+								"name":    "test-container",
+								"envFrom": []interface{}{map[string]interface{}{"secretRef": map[string]interface{}{"name": "test-secret"}}},
+							}},
+						},
+					},
+				},
+			})
+			Expect(err).To(BeNil())
+			patches, err = ti.Inject(plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: ctx.Binding.From,
+					To: corev1alpha1.DataTarget{
+						Env: true,
+					},
+				},
+				Values: ctx.Values,
+			}, runtime.RawExtension{Raw: b})
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(runtime.RawExtension{Raw: b}, []webhook.JSONPatchOp{{
+				Operation: "replace",
+				Path:      "/spec/template/metadata/annotations/secret.checksum.core.oam.dev~1test-secret",
+				Value:     "abc",
+			}}))
+		})
+
+		It("should read injection targets from a file", func() {
+			f, err := ioutil.TempFile("", "targets-*.yaml")
+			Expect(err).To(BeNil())
+			defer os.Remove(f.Name())
+			_, err = f.WriteString(`targets:
+- apiVersion: serving.knative.dev/v1
+  kind: Service
+  podSpecPath: spec.template.spec
+`)
+			Expect(err).To(BeNil())
+			Expect(f.Close()).To(Succeed())
+
+			targets, err := ReadTargetsFile(f.Name())
+			Expect(err).To(BeNil())
+			Expect(targets).To(Equal([]corev1alpha1.InjectionTargetSpec{{
+				APIVersion:  "serving.knative.dev/v1",
+				Kind:        "Service",
+				PodSpecPath: "spec.template.spec",
+			}}))
+			tis, err := PodSpecTargetInjectors(targets)
+			Expect(err).To(BeNil())
+			Expect(tis[0].Match(&admissionv1beta1.AdmissionRequest{
+				Kind: metav1.GroupVersionKind{
+					Group:   "serving.knative.dev",
+					Version: "v1",
+					Kind:    "Service",
+				},
+				Name: "example",
+			}, &corev1alpha1.WorkloadReference{
+				APIVersion: "serving.knative.dev/v1",
+				Kind:       "Service",
+				Name:       "example",
+			})).To(Equal(true))
+		})
+
+		It("should reject invalid injection targets", func() {
+			_, err := NewPodSpecTargetInjector(corev1alpha1.InjectionTargetSpec{
+				APIVersion:  "example.com/v1",
+				Kind:        "App",
+				PodSpecPath: "spec..podSpec",
+			})
+			Expect(err).NotTo(BeNil())
+
+			_, err = NewPodSpecTargetInjector(corev1alpha1.InjectionTargetSpec{
+				APIVersion:  "example.com/v1/v2",
+				Kind:        "App",
+				PodSpecPath: "spec.podSpec",
+			})
+			Expect(err).NotTo(BeNil())
+		})
+	})
+
+	Describe("workload uninjection", func() {
+		It("should remove injected secret keys from Deployment env", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name: "test-secret",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						EnvMappings: []corev1alpha1.EnvMapping{{
+							Key:     "password",
+							EnvName: "DB_PASSWORD",
+						}},
+					},
+				},
+				Values: map[string]interface{}{"secret-name": "test-secret"},
+				Injected: &plugin.Injection{
+					Containers: map[string]*plugin.ContainerInjection{"test-container": {
+						Env: []corev1.EnvVar{makeEnvVar(corev1alpha1.EnvMapping{
+							Key:     "password",
+							EnvName: "DB_PASSWORD",
+						}, "test-secret", "")},
+					}},
+				},
+			}
+			d := &appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Deployment",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-deploy",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+								Env: []corev1.EnvVar{{
+									Name:  "LOG_LEVEL",
+									Value: "debug",
+								}, {
+									Name: "DB_PASSWORD",
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{
+												Name: "test-secret",
+											},
+											Key: "password",
+										},
+									},
+								}},
+							}},
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
+			}
+
+			patches, err := di.Uninject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "remove",
+				Path:      "/spec/template/spec/containers/0/env/1",
+			}}))
+		})
+
+		It("should remove the binding root from StatefulSet once no binding is mounted under it", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name: "test-secret",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						BindingRoot: &corev1alpha1.BindingRootTarget{
+							Name: "db",
+						},
+					},
+				},
+				Values: map[string]interface{}{"secret-name": "test-binding-binding-0"},
+			}
+			rootEnv := corev1.EnvVar{
+				Name:  "SERVICE_BINDING_ROOT",
+				Value: "/bindings",
+			}
+			dbMount := corev1.VolumeMount{
+				Name:      "secret-test-binding-binding-0",
+				MountPath: "/bindings/db",
+			}
+			ctx.Injected = &plugin.Injection{
+				Containers: map[string]*plugin.ContainerInjection{
+					"test-container": {Env: []corev1.EnvVar{rootEnv}, VolumeMounts: []corev1.VolumeMount{dbMount}},
+					"test-sidecar":   {Env: []corev1.EnvVar{rootEnv}, VolumeMounts: []corev1.VolumeMount{dbMount}},
+				},
+				Volumes: []string{"secret-test-binding-binding-0"},
+			}
+			// the binding mounted under the root in the sidecar too
+			ctx.Shared = []*plugin.Injection{{
+				Containers: map[string]*plugin.ContainerInjection{
+					"test-sidecar": {Env: []corev1.EnvVar{rootEnv}, VolumeMounts: []corev1.VolumeMount{{
+						Name:      "secret-test-binding-binding-1",
+						MountPath: "/bindings/cache",
+					}}},
+				},
+				Volumes: []string{"secret-test-binding-binding-1"},
+			}}
+			d := &appsv1.StatefulSet{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "StatefulSet",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-statefulset",
+				},
+				Spec: appsv1.StatefulSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+								Env:  []corev1.EnvVar{rootEnv},
+								VolumeMounts: []corev1.VolumeMount{{
+									Name:      "secret-test-binding-binding-0",
+									MountPath: "/bindings/db",
+								}},
+							}, {
+								Name: "test-sidecar",
+								Env:  []corev1.EnvVar{rootEnv},
+								VolumeMounts: []corev1.VolumeMount{{
+									Name:      "secret-test-binding-binding-0",
+									MountPath: "/bindings/db",
+								}, {
+									Name:      "secret-test-binding-binding-1",
+									MountPath: "/bindings/cache",
+								}},
+							}},
+							Volumes: []corev1.Volume{{
+								Name: "secret-test-binding-binding-0",
+								VolumeSource: corev1.VolumeSource{
+									Secret: &corev1.SecretVolumeSource{
+										SecretName: "test-binding-binding-0",
+									},
+								},
+							}},
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
+			}
+
+			patches, err := si.Uninject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "remove",
+				Path:      "/spec/template/spec/containers/0/env",
+			}, {
+				Operation: "remove",
+				Path:      "/spec/template/spec/containers/0/volumeMounts",
+			}, {
+				Operation: "remove",
+				Path:      "/spec/template/spec/containers/1/volumeMounts/0",
+			}, {
+				Operation: "remove",
+				Path:      "/spec/template/spec/volumes",
+			}}))
+		})
+
+		It("should remove injected secret and volumes from Deployment", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name: "test-secret",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						Env:      true,
+						FilePath: "/test/path",
+					},
+				},
+				Values: map[string]interface{}{"secret-name": "test-secret"},
+				Injected: &plugin.Injection{
+					Containers: map[string]*plugin.ContainerInjection{"test-container": {
+						EnvFrom: []corev1.EnvFromSource{makeEnvFromSource("test-secret", "", "")},
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "secret-test-secret",
+							MountPath: "/test/path",
+						}},
+					}},
+					Volumes: []string{"secret-test-secret"},
+				},
+			}
+			d := &appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Deployment",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-deploy",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name: "test-container",
+								EnvFrom: []corev1.EnvFromSource{{
+									ConfigMapRef: &corev1.ConfigMapEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: "own-config",
+										},
+									},
+								}, {
+									SecretRef: &corev1.SecretEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: "test-secret",
+										},
+									},
+								}},
+								VolumeMounts: []corev1.VolumeMount{{
+									Name:      "secret-test-secret",
+									MountPath: "/test/path",
+								}},
+							}},
+							Volumes: []corev1.Volume{{
+								Name: "own-volume",
+							}, {
+								Name: "secret-test-secret",
+							}},
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
+			}
+
+			patches, err := di.Uninject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "remove",
+				Path:      "/spec/template/spec/containers/0/envFrom/1",
+			}, {
+				Operation: "remove",
+				Path:      "/spec/template/spec/containers/0/volumeMounts",
+			}, {
+				Operation: "remove",
+				Path:      "/spec/template/spec/volumes/1",
+			}}))
+		})
+		It("should keep volume mounted by another container of StatefulSet", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Volume: &corev1alpha1.VolumeSource{
+							PVCName: "test-pvc",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						FilePath: "/test/path",
+					},
+					ContainerSelector: &corev1alpha1.ContainerSelector{
+						ByNames: []string{"test-container"},
+					},
+				},
+				Values: map[string]interface{}{"pvc-name": "test-pvc"},
+			}
+			mounts := []corev1.VolumeMount{{
+				Name:      "pvc-test-pvc",
+				MountPath: "/test/path",
+			}}
+			ctx.Injected = &plugin.Injection{
+				Containers: map[string]*plugin.ContainerInjection{"test-container": {
+					VolumeMounts: mounts,
+				}},
+				Volumes: []string{"pvc-test-pvc"},
+			}
+			d := &appsv1.StatefulSet{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "StatefulSet",
+					APIVersion: "apps/v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test-sts",
+				},
+				Spec: appsv1.StatefulSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name:         "test-container",
+								VolumeMounts: mounts,
+							}, {
+								Name:         "other-container",
+								VolumeMounts: mounts,
+							}},
+							Volumes: []corev1.Volume{{
+								Name: "pvc-test-pvc",
+							}},
+						},
+					},
+				},
+			}
+			b, err := json.Marshal(d)
+			Expect(err).To(BeNil())
+			raw := runtime.RawExtension{
+				Raw: b,
+			}
+
+			patches, err := si.Uninject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "remove",
+				Path:      "/spec/template/spec/containers/0/volumeMounts",
+			}}))
+		})
+		It("should keep the entries of the workload's own alike the injected ones", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name: "db",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						Env: true,
+						EnvMappings: []corev1alpha1.EnvMapping{{
+							Key:     "password",
+							EnvName: "PASSWORD",
+						}},
+					},
+				},
+				Values:   map[string]interface{}{"secret-name": "db"},
+				Injected: &plugin.Injection{},
+			}
+			raw := runtime.RawExtension{
+				Raw: []byte(`{
+					"apiVersion": "apps/v1",
+					"kind": "Deployment",
+					"metadata": {"name": "test-deploy"},
+					"spec": {"template": {"spec": {
+						"containers": [{
+							"name": "test-container",
+							"envFrom": [{"prefix": "DB_", "secretRef": {"name": "db"}}],
+							"env": [{"name": "PASSWORD", "valueFrom": {"secretKeyRef": {"name": "db", "key": "password"}}}]
+						}]
+					}}}
+				}`),
+			}
+
+			patches, err := di.Inject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(patches).To(patchTo(raw, []webhook.JSONPatchOp{{
+				Operation: "add",
+				Path:      "/spec/template/spec/containers/0/envFrom/1",
+				Value:     makeEnvFromSource("db", "", ""),
+			}}))
+			injected := applyPatches(raw.Raw, patches)
+
+			patches, err = di.Uninject(ctx, runtime.RawExtension{Raw: injected})
+			Expect(err).To(BeNil())
+			Expect(applyPatches(injected, patches)).To(MatchJSON(raw.Raw))
+		})
+
+		It("should keep the fields unknown to the API types of the volumes left", func() {
+			ctx := plugin.TargetContext{
+				Binding: &corev1alpha1.Binding{
+					From: corev1alpha1.DataSource{
+						Secret: &corev1alpha1.SecretSource{
+							Name: "db",
+						},
+					},
+					To: corev1alpha1.DataTarget{
+						FilePath: "/etc/db",
+					},
+				},
+				Values: map[string]interface{}{"secret-name": "db"},
+				Injected: &plugin.Injection{
+					Containers: map[string]*plugin.ContainerInjection{"test-container": {
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "secret-db",
+							MountPath: "/etc/db",
+						}},
+					}},
+					Volumes: []string{"secret-db"},
+				},
+			}
+			raw := runtime.RawExtension{
+				Raw: []byte(`{
+					"apiVersion": "apps/v1",
+					"kind": "Deployment",
+					"metadata": {"name": "test-deploy"},
+					"spec": {"template": {"spec": {
+						"containers": [{
+							"name": "test-container",
+							"volumeMounts": [
+								{"name": "secret-db", "mountPath": "/etc/db"},
+								{"name": "scratch", "mountPath": "/scratch", "recursiveReadOnly": "Disabled"}
+							]
+						}],
+						"volumes": [
+							{"name": "secret-db", "secret": {"secretName": "db"}},
+							{"name": "scratch", "ephemeral": {"volumeClaimTemplate": {"spec": {"accessModes": ["ReadWriteOnce"]}}}}
+						]
+					}}}
+				}`),
+			}
+
+			patches, err := di.Uninject(ctx, raw)
+			Expect(err).To(BeNil())
+			Expect(applyPatches(raw.Raw, patches)).To(MatchJSON(`{
+				"apiVersion": "apps/v1",
+				"kind": "Deployment",
+				"metadata": {"name": "test-deploy"},
+				"spec": {"template": {"spec": {
+					"containers": [{
+						"name": "test-container",
+						"volumeMounts": [
+							{"name": "scratch", "mountPath": "/scratch", "recursiveReadOnly": "Disabled"}
+						]
+					}],
+					"volumes": [
+						{"name": "scratch", "ephemeral": {"volumeClaimTemplate": {"spec": {"accessModes": ["ReadWriteOnce"]}}}}
+					]
+				}}}
+			}`))
+		})
+	})
+
+	Describe("request matching", func() {
+		It("should match Deployment injector", func() {
+			req := &admissionv1beta1.AdmissionRequest{
+				Kind: metav1.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "Deployment",
+				},
+				Name: "example",
+			}
+			wref := &corev1alpha1.WorkloadReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       "example",
+			}
+
+			Expect(di.Match(req, wref)).To(Equal(true))
+		})
+
+		It("should not match Deployment injector if name mismatch", func() {
+			req := &admissionv1beta1.AdmissionRequest{
+				Kind: metav1.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "Deployment",
+				},
+				Name: "unmatch-name",
+			}
+			wref := &corev1alpha1.WorkloadReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       "example",
+			}
+
+			Expect(di.Match(req, wref)).To(Equal(false))
+		})
+
+		It("should not match Deployment injector if gvk mismatch", func() {
+			req := &admissionv1beta1.AdmissionRequest{
+				Kind: metav1.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "UnmatchKind",
+				},
+				Name: "example",
+			}
+			wref := &corev1alpha1.WorkloadReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       "example",
+			}
+
+			Expect(di.Match(req, wref)).To(Equal(false))
+		})
+
+		It("should match StatefulSet injector", func() {
+			req := &admissionv1beta1.AdmissionRequest{
+				Kind: metav1.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "StatefulSet",
+				},
+				Name: "example",
+			}
+			wref := &corev1alpha1.WorkloadReference{
+				APIVersion: "apps/v1",
+				Kind:       "StatefulSet",
+				Name:       "example",
+			}
+
+			Expect(si.Match(req, wref)).To(Equal(true))
+		})
+
+		It("should not match StatefulSet injector if name mismatch", func() {
+			req := &admissionv1beta1.AdmissionRequest{
+				Kind: metav1.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "StatefulSet",
+				},
+				Name: "unmatch-name",
+			}
+			wref := &corev1alpha1.WorkloadReference{
+				APIVersion: "apps/v1",
+				Kind:       "StatefulSet",
+				Name:       "example",
+			}
+
+			Expect(si.Match(req, wref)).To(Equal(false))
+		})
+
+		It("should not match StatefulSet injector if gvk mismatch", func() {
+			req := &admissionv1beta1.AdmissionRequest{
+				Kind: metav1.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "UnmatchKind",
+				},
+				Name: "example",
+			}
+			wref := &corev1alpha1.WorkloadReference{
+				APIVersion: "apps/v1",
+				Kind:       "StatefulSet",
+				Name:       "example",
+			}
+
+			Expect(si.Match(req, wref)).To(Equal(false))
+		})
+
+		It("should match DaemonSet, ReplicaSet and Job injectors", func() {
+			for _, c := range []struct {
+				injector plugin.TargetInjector
+				kind     metav1.GroupVersionKind
+			}{
+				{dsi, metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "DaemonSet"}},
+				{rsi, metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}},
+				{ji, metav1.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}},
+			} {
+				req := &admissionv1beta1.AdmissionRequest{
+					Kind: c.kind,
+					Name: "example",
+				}
+				wref := &corev1alpha1.WorkloadReference{
+					APIVersion: c.kind.Group + "/" + c.kind.Version,
+					Kind:       c.kind.Kind,
+					Name:       "example",
+				}
+
+				Expect(c.injector.Match(req, wref)).To(Equal(true))
+				Expect(si.Match(req, wref)).To(Equal(false))
+			}
+		})
+
+		It("should match batch/v1 and batch/v1beta1 CronJob injector", func() {
+			wref := &corev1alpha1.WorkloadReference{
+				APIVersion: "batch/v1",
+				Kind:       "CronJob",
+				Name:       "example",
+			}
+			for _, version := range []string{"v1", "v1beta1"} {
+				req := &admissionv1beta1.AdmissionRequest{
+					Kind: metav1.GroupVersionKind{
+						Group:   "batch",
+						Version: version,
+						Kind:    "CronJob",
+					},
+					Name: "example",
+				}
+
+				Expect(ci.Match(req, wref)).To(Equal(true))
+				Expect(ji.Match(req, wref)).To(Equal(false))
+			}
 		})
-
 	})
 })
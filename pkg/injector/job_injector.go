@@ -0,0 +1,69 @@
+package injector
+
+import (
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	"github.com/oam-dev/trait-injector/pkg/plugin"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var _ plugin.TargetInjector = &JobTargetInjector{}
+var _ plugin.KindLister = &JobTargetInjector{}
+
+type JobTargetInjector struct {
+	Log logr.Logger
+}
+
+func newJobTargetInjector() *JobTargetInjector {
+	return &JobTargetInjector{
+		Log: ctrl.Log.WithName("targetInjectors").WithName("Job"),
+	}
+}
+
+func (ti *JobTargetInjector) Name() string {
+	return "JobTargetInjector"
+}
+
+func (ti *JobTargetInjector) Match(req *admissionv1beta1.AdmissionRequest, w *corev1alpha1.WorkloadReference) bool {
+	k := req.Kind
+	if k.Group == "batch" && k.Version == "v1" && k.Kind == "Job" && req.Name == w.Name {
+		return true
+	}
+	return false
+}
+
+func (ti *JobTargetInjector) Kinds() []schema.GroupVersionKind {
+	return []schema.GroupVersionKind{{Group: "batch", Version: "v1", Kind: "Job"}}
+}
+
+func (ti *JobTargetInjector) Inject(ctx plugin.TargetContext, raw runtime.RawExtension) ([]webhook.JSONPatchOp, error) {
+	t, err := ti.podTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return makeInjectPatches(ti.Log, ctx, t)
+}
+
+func (ti *JobTargetInjector) Uninject(ctx plugin.TargetContext, raw runtime.RawExtension) ([]webhook.JSONPatchOp, error) {
+	t, err := ti.podTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return makeUninjectPatches(ti.Log, ctx, t)
+}
+
+// podTemplate returns the pod template of the Job.
+func (ti *JobTargetInjector) podTemplate(raw runtime.RawExtension) (*podTemplate, error) {
+	job := &batchv1.Job{}
+	if err := json.Unmarshal(raw.Raw, job); err != nil {
+		return nil, err
+	}
+	return newPodTemplate(raw.Raw, job.ObjectMeta, &job.Spec.Template, podTemplatePath), nil
+}
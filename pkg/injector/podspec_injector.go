@@ -0,0 +1,155 @@
+package injector
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	"github.com/oam-dev/trait-injector/pkg/plugin"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var _ plugin.TargetInjector = &PodSpecTargetInjector{}
+var _ plugin.KindLister = &PodSpecTargetInjector{}
+var _ plugin.PodSpecReader = &PodSpecTargetInjector{}
+
+// PodSpecTargetInjector injects bindings into the pod spec at a path of the
+// workloads of a kind, read as unstructured objects, so that workload kinds
+// without a target injector of their own can be targeted.
+type PodSpecTargetInjector struct {
+	Log logr.Logger
+
+	gvk schema.GroupVersionKind
+
+	// fields of the pod spec, and the JSON pointers of the pod spec and of
+	// the pod template it is the spec of, if any
+	specFields   []string
+	specPath     string
+	templatePath string
+}
+
+// NewPodSpecTargetInjector returns the target injector of the workloads
+// described by the InjectionTarget spec.
+func NewPodSpecTargetInjector(t corev1alpha1.InjectionTargetSpec) (*PodSpecTargetInjector, error) {
+	gv, err := schema.ParseGroupVersion(t.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("apiVersion %s: %w", t.APIVersion, err)
+	}
+	if len(t.Kind) == 0 {
+		return nil, fmt.Errorf("kind of %s is required", t.APIVersion)
+	}
+	fields := strings.Split(strings.TrimPrefix(t.PodSpecPath, "."), ".")
+	for _, f := range fields {
+		if len(f) == 0 {
+			return nil, fmt.Errorf("invalid podSpecPath %q of %s %s", t.PodSpecPath, t.APIVersion, t.Kind)
+		}
+	}
+
+	ti := &PodSpecTargetInjector{
+		Log:        ctrl.Log.WithName("targetInjectors").WithName("PodSpec").WithValues("apiVersion", t.APIVersion, "kind", t.Kind),
+		gvk:        gv.WithKind(t.Kind),
+		specFields: fields,
+		specPath:   makeJSONPointer(fields),
+	}
+	if n := len(fields); n > 1 && fields[n-1] == "spec" {
+		ti.templatePath = makeJSONPointer(fields[:n-1])
+	}
+	return ti, nil
+}
+
+// makeJSONPointer returns the JSON pointer of the fields.
+func makeJSONPointer(fields []string) string {
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString("/")
+		b.WriteString(plugin.EscapeJSONPointer(f))
+	}
+	return b.String()
+}
+
+func (ti *PodSpecTargetInjector) Name() string {
+	return "PodSpecTargetInjector(" + ti.gvk.String() + ")"
+}
+
+func (ti *PodSpecTargetInjector) Match(req *admissionv1beta1.AdmissionRequest, w *corev1alpha1.WorkloadReference) bool {
+	k := req.Kind
+	if k.Group == ti.gvk.Group && k.Version == ti.gvk.Version && k.Kind == ti.gvk.Kind && req.Name == w.Name {
+		return true
+	}
+	return false
+}
+
+func (ti *PodSpecTargetInjector) Kinds() []schema.GroupVersionKind {
+	return []schema.GroupVersionKind{ti.gvk}
+}
+
+// PodSpec returns the pod spec at the path of the workload.
+func (ti *PodSpecTargetInjector) PodSpec(raw runtime.RawExtension) (*corev1.PodSpec, error) {
+	t, err := ti.podTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return t.Spec, nil
+}
+
+// podTemplate returns the pod spec at the path of the workload, and the
+// annotations of the pod template it is part of.
+func (ti *PodSpecTargetInjector) podTemplate(raw runtime.RawExtension) (*podTemplate, error) {
+	u := &unstructured.Unstructured{}
+	if err := json.Unmarshal(raw.Raw, &u.Object); err != nil {
+		return nil, err
+	}
+	name := path.Join(u.GetNamespace(), u.GetName())
+
+	m, found, err := unstructured.NestedMap(u.Object, ti.specFields...)
+	if err != nil {
+		return nil, fmt.Errorf("pod spec of %s: %w", name, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("pod spec of %s not found at %s", name, strings.Join(ti.specFields, "."))
+	}
+	t := &podTemplate{
+		Name:         name,
+		Raw:          raw.Raw,
+		Spec:         &corev1.PodSpec{},
+		SpecPath:     ti.specPath,
+		TemplatePath: ti.templatePath,
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, t.Spec); err != nil {
+		return nil, fmt.Errorf("pod spec of %s: %w", name, err)
+	}
+
+	if len(ti.templatePath) != 0 {
+		fields := append(ti.specFields[:len(ti.specFields)-1:len(ti.specFields)-1], "metadata", "annotations")
+		t.Annotations, _, err = unstructured.NestedStringMap(u.Object, fields...)
+		if err != nil {
+			return nil, fmt.Errorf("pod template annotations of %s: %w", name, err)
+		}
+	}
+	return t, nil
+}
+
+func (ti *PodSpecTargetInjector) Inject(ctx plugin.TargetContext, raw runtime.RawExtension) ([]webhook.JSONPatchOp, error) {
+	t, err := ti.podTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return makeInjectPatches(ti.Log, ctx, t)
+}
+
+func (ti *PodSpecTargetInjector) Uninject(ctx plugin.TargetContext, raw runtime.RawExtension) ([]webhook.JSONPatchOp, error) {
+	t, err := ti.podTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return makeUninjectPatches(ti.Log, ctx, t)
+}
@@ -0,0 +1,215 @@
+package injector
+
+import (
+	"path"
+
+	"github.com/go-logr/logr"
+	"github.com/oam-dev/trait-injector/pkg/plugin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// podTemplate is the pod spec of a workload, as located by its target
+// injector, that makeInjectPatches and makeUninjectPatches patch.
+type podTemplate struct {
+	// Name is the namespaced name of the workload, and Raw the workload the
+	// patches are made against.
+	Name string
+	Raw  []byte
+
+	// Spec is the pod spec, at the JSON pointer SpecPath.
+	Spec     *corev1.PodSpec
+	SpecPath string
+
+	// Annotations are the annotations of the pod template the pod spec is
+	// part of, at the JSON pointer TemplatePath. TemplatePath is empty if the
+	// pod spec is not part of a pod template.
+	Annotations  map[string]string
+	TemplatePath string
+}
+
+// newPodTemplate returns the pod template of the raw workload, at the JSON
+// pointer.
+func newPodTemplate(raw []byte, meta metav1.ObjectMeta, t *corev1.PodTemplateSpec, templatePath string) *podTemplate {
+	return &podTemplate{
+		Name:         path.Join(meta.Namespace, meta.Name),
+		Raw:          raw,
+		Spec:         &t.Spec,
+		SpecPath:     templatePath + "/spec",
+		Annotations:  t.Annotations,
+		TemplatePath: templatePath,
+	}
+}
+
+// DeepCopy returns a copy of the pod template to mutate.
+func (t *podTemplate) DeepCopy() *podTemplate {
+	c := *t
+	c.Spec = t.Spec.DeepCopy()
+	if t.Annotations != nil {
+		c.Annotations = make(map[string]string, len(t.Annotations))
+		for k, v := range t.Annotations {
+			c.Annotations[k] = v
+		}
+	}
+	return &c
+}
+
+// makePatches returns the patches turning the pod template into the mutated
+// copy of it. The metadata of the pod template is added whole if the workload
+// has none, as Jobs and CronJobs often do.
+func (t *podTemplate) makePatches(mutated *podTemplate) ([]webhook.JSONPatchOp, error) {
+	patches, err := plugin.CreatePatch(t.Raw, t.SpecPath, t.Spec, mutated.Spec)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.TemplatePath) == 0 {
+		return patches, nil
+	}
+	type metadata struct {
+		Annotations map[string]string `json:"annotations,omitempty"`
+	}
+	p, err := plugin.CreatePatch(t.Raw, t.TemplatePath+"/metadata", metadata{t.Annotations}, metadata{mutated.Annotations})
+	if err != nil {
+		return nil, err
+	}
+	return append(patches, p...), nil
+}
+
+// makeInjectPatches returns the patches injecting the binding of the context
+// into the selected containers of the pod template.
+func makeInjectPatches(log logr.Logger, ctx plugin.TargetContext, t *podTemplate) ([]webhook.JSONPatchOp, error) {
+	// the injection of the context is only updated if the patches are made
+	injected := ctx.Injected
+	ctx.Injected = injected.DeepCopy()
+	mutated := t.DeepCopy()
+	if err := injectPodTemplate(log, ctx, mutated); err != nil {
+		return nil, err
+	}
+	patches, err := t.makePatches(mutated)
+	if err != nil {
+		return nil, err
+	}
+	if injected != nil {
+		*injected = *ctx.Injected
+	}
+	return patches, nil
+}
+
+// makeUninjectPatches returns the patches removing the entries recorded in
+// the injection of the context by makeInjectPatches from the pod template.
+func makeUninjectPatches(log logr.Logger, ctx plugin.TargetContext, t *podTemplate) ([]webhook.JSONPatchOp, error) {
+	mutated := t.DeepCopy()
+	uninjectPodTemplate(log, ctx, mutated)
+	return t.makePatches(mutated)
+}
+
+// injectPodTemplate injects the binding of the context into the selected
+// containers of the pod template, recording the entries injected into the
+// injection of the context.
+func injectPodTemplate(log logr.Logger, ctx plugin.TargetContext, t *podTemplate) error {
+	b := ctx.Binding
+	r := newRecord(ctx)
+	secretName, configMapName, pvcName := getValues(ctx)
+	envFrom := makeEnvFromSource(secretName, configMapName, b.To.Prefix)
+	// Inject secret or configmap to env
+	if b.To.Env {
+		for i := range t.Spec.Containers {
+			c := &t.Spec.Containers[i]
+			if !isSelected(b, *c) {
+				continue
+			}
+			// already in place, recorded unless the workload's own
+			if indexEnvFrom(c.EnvFrom, envFrom) >= 0 {
+				if hasEnvFrom(r.owners(), c.Name, envFrom) {
+					r.addEnvFrom(c.Name, envFrom)
+				}
+				continue
+			}
+			c.EnvFrom = append(c.EnvFrom, envFrom)
+			r.addEnvFrom(c.Name, envFrom)
+		}
+		log.Info("injected secret to env", "secret", secretName, "configMap", configMapName, "workload", t.Name)
+	}
+
+	// Inject keys of secret or configmap to individual env vars, and the
+	// binding root of bindings mounted under it
+	if len(b.To.EnvMappings) != 0 || b.To.BindingRoot != nil {
+		vars := make([]corev1.EnvVar, 0, len(b.To.EnvMappings))
+		for _, m := range b.To.EnvMappings {
+			vars = append(vars, makeEnvVar(m, secretName, configMapName))
+		}
+		for i := range t.Spec.Containers {
+			c := &t.Spec.Containers[i]
+			if !isSelected(b, *c) {
+				continue
+			}
+			if err := injectEnvVars(c, vars, r); err != nil {
+				return err
+			}
+			if b.To.BindingRoot != nil {
+				injectBindingRootEnvVar(c, r)
+			}
+		}
+		log.Info("injected keys to env", "secret", secretName, "configMap", configMapName, "workload", t.Name)
+	}
+
+	// inject secret as file in Pod
+	if mountsFile(b) {
+		if err := injectVolumes(t.Spec, b, secretName, configMapName, pvcName, r); err != nil {
+			return err
+		}
+		log.Info("injected volume to file", "workload", t.Name)
+	}
+
+	// roll the pods when the secret data changes, if the pod spec is part of
+	// a pod template
+	if checksum := getChecksum(ctx); len(t.TemplatePath) != 0 && len(secretName) != 0 && len(checksum) != 0 {
+		t.Annotations = setChecksumAnnotation(t.Annotations, secretName, checksum)
+		r.addAnnotation(makeChecksumAnnotation(secretName))
+	}
+	return nil
+}
+
+// uninjectPodTemplate removes the entries recorded in the injection of the
+// context from the pod template, but the ones shared with other
+// ServiceBindings.
+func uninjectPodTemplate(log logr.Logger, ctx plugin.TargetContext, t *podTemplate) {
+	in := ctx.Injected
+	if in == nil {
+		return
+	}
+	for i := range t.Spec.Containers {
+		c := &t.Spec.Containers[i]
+		injected := containerInjection(in, c.Name)
+		if injected == nil {
+			continue
+		}
+		for _, e := range injected.EnvFrom {
+			if !hasEnvFrom(ctx.Shared, c.Name, e) {
+				c.EnvFrom = removeEnvFrom(c.EnvFrom, e)
+			}
+		}
+		for _, v := range injected.Env {
+			if !hasEnvVar(ctx.Shared, c.Name, v) {
+				c.Env = removeEnvVar(c.Env, v)
+			}
+		}
+		for _, m := range injected.VolumeMounts {
+			if !hasVolumeMount(ctx.Shared, c.Name, m) {
+				c.VolumeMounts = removeVolumeMount(c.VolumeMounts, m)
+			}
+		}
+	}
+	log.Info("removed injected env and volume mounts", "workload", t.Name)
+
+	uninjectVolumes(t.Spec, in, ctx.Shared)
+	log.Info("removed injected volumes", "workload", t.Name)
+
+	// Remove the checksum annotations rolling the pods
+	for _, k := range in.Annotations {
+		if !hasAnnotation(ctx.Shared, k) {
+			delete(t.Annotations, k)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package injector
+
+import (
+	"reflect"
+
+	"github.com/oam-dev/trait-injector/pkg/plugin"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// record records the entries injected for a binding into the injection of
+// its context. The entries found in place are recorded if injected for the
+// ServiceBinding already or shared with other ServiceBindings, and left to
+// the workload otherwise.
+type record struct {
+	injected *plugin.Injection
+	shared   []*plugin.Injection
+}
+
+// newRecord returns the record of the injection of the context, recording
+// into a new injection if the context has none.
+func newRecord(ctx plugin.TargetContext) *record {
+	in := ctx.Injected
+	if in == nil {
+		in = &plugin.Injection{}
+	}
+	return &record{
+		injected: in,
+		shared:   ctx.Shared,
+	}
+}
+
+// owners returns the injections recording the entries injected, for the
+// ServiceBinding or the other ones.
+func (r *record) owners() []*plugin.Injection {
+	return append([]*plugin.Injection{r.injected}, r.shared...)
+}
+
+// container returns the entries recorded as injected into the named
+// container, added if none.
+func (r *record) container(name string) *plugin.ContainerInjection {
+	if r.injected.Containers == nil {
+		r.injected.Containers = map[string]*plugin.ContainerInjection{}
+	}
+	c, ok := r.injected.Containers[name]
+	if !ok {
+		c = &plugin.ContainerInjection{}
+		r.injected.Containers[name] = c
+	}
+	return c
+}
+
+func (r *record) addEnvFrom(container string, e corev1.EnvFromSource) {
+	c := r.container(container)
+	if indexEnvFrom(c.EnvFrom, e) < 0 {
+		c.EnvFrom = append(c.EnvFrom, e)
+	}
+}
+
+func (r *record) addEnvVar(container string, v corev1.EnvVar) {
+	c := r.container(container)
+	if indexEnvVar(c.Env, v) < 0 {
+		c.Env = append(c.Env, v)
+	}
+}
+
+func (r *record) removeEnvVar(container string, v corev1.EnvVar) {
+	c := r.container(container)
+	c.Env = removeEnvVar(c.Env, v)
+}
+
+func (r *record) addVolumeMount(container string, m corev1.VolumeMount) {
+	c := r.container(container)
+	if indexVolumeMount(c.VolumeMounts, m) < 0 {
+		c.VolumeMounts = append(c.VolumeMounts, m)
+	}
+}
+
+func (r *record) removeVolumeMount(container string, m corev1.VolumeMount) {
+	c := r.container(container)
+	c.VolumeMounts = removeVolumeMount(c.VolumeMounts, m)
+}
+
+func (r *record) addVolume(name string) {
+	if _, ok := FindString(r.injected.Volumes, name); !ok {
+		r.injected.Volumes = append(r.injected.Volumes, name)
+	}
+}
+
+// addProjection records the source projected into the volume, replacing the
+// one recorded of the same source.
+func (r *record) addProjection(volume string, p corev1.VolumeProjection) {
+	if r.injected.Projections == nil {
+		r.injected.Projections = map[string][]corev1.VolumeProjection{}
+	}
+	sources := r.injected.Projections[volume]
+	if s := findProjection(sources, p); s >= 0 {
+		sources[s] = p
+		return
+	}
+	r.injected.Projections[volume] = append(sources, p)
+}
+
+func (r *record) addAnnotation(key string) {
+	if _, ok := FindString(r.injected.Annotations, key); !ok {
+		r.injected.Annotations = append(r.injected.Annotations, key)
+	}
+}
+
+// containerInjection returns the entries the injection records as injected
+// into the named container, or nil if none.
+func containerInjection(in *plugin.Injection, name string) *plugin.ContainerInjection {
+	if in == nil {
+		return nil
+	}
+	return in.Containers[name]
+}
+
+// hasEnvFrom tells whether one of the injections records the envFrom source
+// of the container.
+func hasEnvFrom(ins []*plugin.Injection, container string, e corev1.EnvFromSource) bool {
+	for _, in := range ins {
+		if c := containerInjection(in, container); c != nil && indexEnvFrom(c.EnvFrom, e) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEnvVar tells whether one of the injections records the env var of the
+// container.
+func hasEnvVar(ins []*plugin.Injection, container string, v corev1.EnvVar) bool {
+	for _, in := range ins {
+		if c := containerInjection(in, container); c != nil && indexEnvVar(c.Env, v) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasVolumeMount tells whether one of the injections records the volume
+// mount of the container.
+func hasVolumeMount(ins []*plugin.Injection, container string, m corev1.VolumeMount) bool {
+	for _, in := range ins {
+		if c := containerInjection(in, container); c != nil && indexVolumeMount(c.VolumeMounts, m) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasVolume tells whether one of the injections records the named volume.
+func hasVolume(ins []*plugin.Injection, name string) bool {
+	for _, in := range ins {
+		if in == nil {
+			continue
+		}
+		if _, ok := FindString(in.Volumes, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasProjection tells whether one of the injections records the source
+// projected into the volume.
+func hasProjection(ins []*plugin.Injection, volume string, p corev1.VolumeProjection) bool {
+	for _, in := range ins {
+		if in != nil && findProjection(in.Projections[volume], p) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnnotation tells whether one of the injections records the pod template
+// annotation.
+func hasAnnotation(ins []*plugin.Injection, key string) bool {
+	for _, in := range ins {
+		if in == nil {
+			continue
+		}
+		if _, ok := FindString(in.Annotations, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// indexEnvFrom returns the index of the last envFrom source equal to the
+// given one, or -1 if not found.
+func indexEnvFrom(envFrom []corev1.EnvFromSource, e corev1.EnvFromSource) int {
+	for i := len(envFrom) - 1; i >= 0; i-- {
+		if reflect.DeepEqual(envFrom[i], e) {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexEnvVar returns the index of the last env var equal to the given one,
+// or -1 if not found.
+func indexEnvVar(env []corev1.EnvVar, v corev1.EnvVar) int {
+	for i := len(env) - 1; i >= 0; i-- {
+		if reflect.DeepEqual(env[i], v) {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexVolumeMount returns the index of the last volume mount equal to the
+// given one, or -1 if not found.
+func indexVolumeMount(mounts []corev1.VolumeMount, m corev1.VolumeMount) int {
+	for i := len(mounts) - 1; i >= 0; i-- {
+		if reflect.DeepEqual(mounts[i], m) {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeEnvFrom removes the last envFrom source equal to the given one.
+func removeEnvFrom(envFrom []corev1.EnvFromSource, e corev1.EnvFromSource) []corev1.EnvFromSource {
+	i := indexEnvFrom(envFrom, e)
+	if i < 0 {
+		return envFrom
+	}
+	if len(envFrom) == 1 {
+		return nil
+	}
+	return append(envFrom[:i:i], envFrom[i+1:]...)
+}
+
+// removeEnvVar removes the last env var equal to the given one.
+func removeEnvVar(env []corev1.EnvVar, v corev1.EnvVar) []corev1.EnvVar {
+	i := indexEnvVar(env, v)
+	if i < 0 {
+		return env
+	}
+	if len(env) == 1 {
+		return nil
+	}
+	return append(env[:i:i], env[i+1:]...)
+}
+
+// removeVolumeMount removes the last volume mount equal to the given one.
+func removeVolumeMount(mounts []corev1.VolumeMount, m corev1.VolumeMount) []corev1.VolumeMount {
+	i := indexVolumeMount(mounts, m)
+	if i < 0 {
+		return mounts
+	}
+	if len(mounts) == 1 {
+		return nil
+	}
+	return append(mounts[:i:i], mounts[i+1:]...)
+}
@@ -0,0 +1,69 @@
+package injector
+
+import (
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	"github.com/oam-dev/trait-injector/pkg/plugin"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var _ plugin.TargetInjector = &ReplicasetTargetInjector{}
+var _ plugin.KindLister = &ReplicasetTargetInjector{}
+
+type ReplicasetTargetInjector struct {
+	Log logr.Logger
+}
+
+func newReplicasetTargetInjector() *ReplicasetTargetInjector {
+	return &ReplicasetTargetInjector{
+		Log: ctrl.Log.WithName("targetInjectors").WithName("Replicaset"),
+	}
+}
+
+func (ti *ReplicasetTargetInjector) Name() string {
+	return "ReplicasetTargetInjector"
+}
+
+func (ti *ReplicasetTargetInjector) Match(req *admissionv1beta1.AdmissionRequest, w *corev1alpha1.WorkloadReference) bool {
+	k := req.Kind
+	if k.Group == "apps" && k.Version == "v1" && k.Kind == "ReplicaSet" && req.Name == w.Name {
+		return true
+	}
+	return false
+}
+
+func (ti *ReplicasetTargetInjector) Kinds() []schema.GroupVersionKind {
+	return []schema.GroupVersionKind{{Group: "apps", Version: "v1", Kind: "ReplicaSet"}}
+}
+
+func (ti *ReplicasetTargetInjector) Inject(ctx plugin.TargetContext, raw runtime.RawExtension) ([]webhook.JSONPatchOp, error) {
+	t, err := ti.podTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return makeInjectPatches(ti.Log, ctx, t)
+}
+
+func (ti *ReplicasetTargetInjector) Uninject(ctx plugin.TargetContext, raw runtime.RawExtension) ([]webhook.JSONPatchOp, error) {
+	t, err := ti.podTemplate(raw)
+	if err != nil {
+		return nil, err
+	}
+	return makeUninjectPatches(ti.Log, ctx, t)
+}
+
+// podTemplate returns the pod template of the ReplicaSet.
+func (ti *ReplicasetTargetInjector) podTemplate(raw runtime.RawExtension) (*podTemplate, error) {
+	replicaSet := &appsv1.ReplicaSet{}
+	if err := json.Unmarshal(raw.Raw, replicaSet); err != nil {
+		return nil, err
+	}
+	return newPodTemplate(raw.Raw, replicaSet.ObjectMeta, &replicaSet.Spec.Template, podTemplatePath), nil
+}
@@ -0,0 +1,46 @@
+package injector
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	"github.com/oam-dev/trait-injector/pkg/plugin"
+	"sigs.k8s.io/yaml"
+)
+
+// targetsFile is the file listing injection targets, e.g.
+//
+//	targets:
+//	- apiVersion: serving.knative.dev/v1
+//	  kind: Service
+//	  podSpecPath: spec.template.spec
+type targetsFile struct {
+	Targets []corev1alpha1.InjectionTargetSpec `json:"targets"`
+}
+
+// ReadTargetsFile returns the injection targets listed in the YAML file.
+func ReadTargetsFile(name string) ([]corev1alpha1.InjectionTargetSpec, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	f := &targetsFile{}
+	if err := yaml.UnmarshalStrict(b, f); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return f.Targets, nil
+}
+
+// PodSpecTargetInjectors returns the target injectors of the injection targets.
+func PodSpecTargetInjectors(targets []corev1alpha1.InjectionTargetSpec) ([]plugin.TargetInjector, error) {
+	tis := make([]plugin.TargetInjector, 0, len(targets))
+	for _, t := range targets {
+		ti, err := NewPodSpecTargetInjector(t)
+		if err != nil {
+			return nil, err
+		}
+		tis = append(tis, ti)
+	}
+	return tis, nil
+}
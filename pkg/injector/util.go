@@ -1,38 +1,104 @@
 package injector
 
 import (
+	"fmt"
+	"path"
+	"reflect"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
 	"github.com/oam-dev/trait-injector/pkg/plugin"
 
 	corev1 "k8s.io/api/core/v1"
 )
 
-func makeVolumeMountName(secretName, pvcName string) string {
+const (
+	// serviceBindingRootEnv is the env var holding the directory bindings are
+	// mounted under, per the Service Binding for Kubernetes specification.
+	serviceBindingRootEnv = "SERVICE_BINDING_ROOT"
+
+	// defaultServiceBindingRoot is the binding root of the containers that do
+	// not set SERVICE_BINDING_ROOT.
+	defaultServiceBindingRoot = "/bindings"
+
+	// podTemplatePath is the JSON pointer of the pod template of workloads
+	// such as Deployments, StatefulSets and Jobs.
+	podTemplatePath = "/spec/template"
+)
+
+func makeVolumeMountName(secretName, configMapName, pvcName string) string {
 	var s string
 	if len(secretName) != 0 {
 		s = "secret-" + secretName
+	} else if len(configMapName) != 0 {
+		s = "configmap-" + configMapName
 	} else if len(pvcName) != 0 {
 		s = "pvc-" + pvcName
 	}
 	return s
 }
 
-func getValues(ctx plugin.TargetContext) (string, string) {
-	var secretName, pvcName string
+func getValues(ctx plugin.TargetContext) (string, string, string) {
+	var secretName, configMapName, pvcName string
 	if val, ok := ctx.Values["secret-name"]; ok {
 		secretName = val.(string)
 	}
+	if val, ok := ctx.Values["configmap-name"]; ok {
+		configMapName = val.(string)
+	}
 	if val, ok := ctx.Values["pvc-name"]; ok {
 		pvcName = val.(string)
 	}
-	return secretName, pvcName
+	return secretName, configMapName, pvcName
 }
 
-func makeVolumeSource(secretName, pvcName string) corev1.VolumeSource {
+// getChecksum returns the checksum of the secret data to annotate the pod
+// template with, or an empty string if the workload is not rolled on change.
+func getChecksum(ctx plugin.TargetContext) string {
+	if val, ok := ctx.Values["secret-checksum"]; ok {
+		return val.(string)
+	}
+	return ""
+}
+
+// makeChecksumAnnotation returns the pod template annotation holding the
+// checksum of the secret data.
+func makeChecksumAnnotation(secretName string) string {
+	return "secret.checksum.core.oam.dev/" + secretName
+}
+
+// setChecksumAnnotation returns the pod template annotations with the
+// checksum annotation of the secret set.
+func setChecksumAnnotation(annotations map[string]string, secretName, checksum string) map[string]string {
+	if len(secretName) == 0 || len(checksum) == 0 {
+		return annotations
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[makeChecksumAnnotation(secretName)] = checksum
+	return annotations
+}
+
+// makeVolumeSource returns the volume source of the secret, configmap or pvc,
+// with the items and default mode of the target.
+func makeVolumeSource(t corev1alpha1.DataTarget, secretName, configMapName, pvcName string) corev1.VolumeSource {
 	var vs corev1.VolumeSource
 	if len(secretName) != 0 {
 		vs = corev1.VolumeSource{
 			Secret: &corev1.SecretVolumeSource{
-				SecretName: secretName,
+				SecretName:  secretName,
+				Items:       t.Items,
+				DefaultMode: t.DefaultMode,
+			},
+		}
+	} else if len(configMapName) != 0 {
+		vs = corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: configMapName,
+				},
+				Items:       t.Items,
+				DefaultMode: t.DefaultMode,
 			},
 		}
 	} else if len(pvcName) != 0 {
@@ -45,6 +111,209 @@ func makeVolumeSource(secretName, pvcName string) corev1.VolumeSource {
 	return vs
 }
 
+// sameVolumeSource tells whether the volume source a refers to the same data
+// as b, with the same items and modes. Modes defaulted by the API server in a
+// are ignored if not set in b.
+func sameVolumeSource(a, b corev1.VolumeSource) bool {
+	switch {
+	case a.Secret != nil && b.Secret != nil:
+		return a.Secret.SecretName == b.Secret.SecretName && sameKeyToPaths(a.Secret.Items, b.Secret.Items) &&
+			sameDefaultMode(a.Secret.DefaultMode, b.Secret.DefaultMode)
+	case a.ConfigMap != nil && b.ConfigMap != nil:
+		return a.ConfigMap.Name == b.ConfigMap.Name && sameKeyToPaths(a.ConfigMap.Items, b.ConfigMap.Items) &&
+			sameDefaultMode(a.ConfigMap.DefaultMode, b.ConfigMap.DefaultMode)
+	case a.PersistentVolumeClaim != nil && b.PersistentVolumeClaim != nil:
+		return a.PersistentVolumeClaim.ClaimName == b.PersistentVolumeClaim.ClaimName
+	}
+	return false
+}
+
+// sameDefaultMode tells whether the default mode a is the mode b, if set.
+func sameDefaultMode(a, b *int32) bool {
+	return b == nil || a != nil && *a == *b
+}
+
+// sameKeyToPaths tells whether the items map the same keys to the same paths
+// and modes.
+func sameKeyToPaths(a, b []corev1.KeyToPath) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key || a[i].Path != b[i].Path {
+			return false
+		}
+		if (a[i].Mode == nil) != (b[i].Mode == nil) || a[i].Mode != nil && *a[i].Mode != *b[i].Mode {
+			return false
+		}
+	}
+	return true
+}
+
+// makeEnvFromSource returns the envFrom source referring to the configmap if
+// given, or else to the secret.
+func makeEnvFromSource(secretName, configMapName, prefix string) corev1.EnvFromSource {
+	if len(configMapName) != 0 {
+		return corev1.EnvFromSource{
+			Prefix: prefix,
+			ConfigMapRef: &corev1.ConfigMapEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: configMapName,
+				},
+			},
+		}
+	}
+	return corev1.EnvFromSource{
+		Prefix: prefix,
+		SecretRef: &corev1.SecretEnvSource{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: secretName,
+			},
+		},
+	}
+}
+
+// makeEnvVar returns the env var of the mapping, referring to the key of the
+// configmap if given, or else of the secret.
+func makeEnvVar(m corev1alpha1.EnvMapping, secretName, configMapName string) corev1.EnvVar {
+	name := m.EnvName
+	if len(name) == 0 {
+		name = m.Key
+	}
+	var optional *bool
+	if m.Optional {
+		optional = &m.Optional
+	}
+	if len(configMapName) != 0 {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: configMapName,
+					},
+					Key:      m.Key,
+					Optional: optional,
+				},
+			},
+		}
+	}
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+				Key:      m.Key,
+				Optional: optional,
+			},
+		},
+	}
+}
+
+// injectEnvVars adds the env vars to the container, recording them as
+// injected. An env var of the same name that differs is replaced only if
+// injected for the ServiceBinding before, as the workload or another
+// ServiceBinding may set it otherwise.
+func injectEnvVars(c *corev1.Container, vars []corev1.EnvVar, r *record) error {
+	for _, v := range vars {
+		j := findEnvVar(c.Env, v.Name)
+		switch {
+		case j < 0:
+			c.Env = append(c.Env, v)
+		case reflect.DeepEqual(c.Env[j], v):
+			// already in place, recorded unless the workload's own
+			if !hasEnvVar(r.owners(), c.Name, v) {
+				continue
+			}
+		case hasEnvVar([]*plugin.Injection{r.injected}, c.Name, c.Env[j]):
+			r.removeEnvVar(c.Name, c.Env[j])
+			c.Env[j] = v
+		case hasEnvVar(r.shared, c.Name, c.Env[j]):
+			return fmt.Errorf("env var %s of container %s is set by another ServiceBinding", v.Name, c.Name)
+		default:
+			return fmt.Errorf("env var %s of container %s is set by the workload", v.Name, c.Name)
+		}
+		r.addEnvVar(c.Name, v)
+	}
+	return nil
+}
+
+// findEnvVar returns the index of the named env var, or -1 if not found.
+func findEnvVar(env []corev1.EnvVar, name string) int {
+	for i, e := range env {
+		if e.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// mountsFile tells whether the binding mounts its data source as files.
+func mountsFile(b *corev1alpha1.Binding) bool {
+	return len(b.To.FilePath) != 0 || b.To.BindingRoot != nil
+}
+
+// makeMountPath returns the path the data source of the binding is mounted at
+// in the container, which is under its binding root if BindingRoot is set.
+func makeMountPath(b *corev1alpha1.Binding, c corev1.Container) string {
+	if r := b.To.BindingRoot; r != nil {
+		return path.Join(serviceBindingRoot(c), r.Name)
+	}
+	return b.To.FilePath
+}
+
+// serviceBindingRoot returns the binding root set by the SERVICE_BINDING_ROOT
+// env var of the container, or the default one.
+func serviceBindingRoot(c corev1.Container) string {
+	for _, e := range c.Env {
+		if e.Name == serviceBindingRootEnv && e.ValueFrom == nil && len(e.Value) != 0 {
+			return e.Value
+		}
+	}
+	return defaultServiceBindingRoot
+}
+
+// injectBindingRootEnvVar sets the default SERVICE_BINDING_ROOT env var in
+// the container, unless it sets one already, recording it as injected.
+func injectBindingRootEnvVar(c *corev1.Container, r *record) {
+	v := corev1.EnvVar{
+		Name:  serviceBindingRootEnv,
+		Value: defaultServiceBindingRoot,
+	}
+	if j := findEnvVar(c.Env, serviceBindingRootEnv); j >= 0 {
+		// recorded if shared with other bindings mounted under the root
+		if reflect.DeepEqual(c.Env[j], v) && hasEnvVar(r.owners(), c.Name, v) {
+			r.addEnvVar(c.Name, v)
+		}
+		return
+	}
+	c.Env = append(c.Env, v)
+	r.addEnvVar(c.Name, v)
+}
+
+// findVolume returns the index of the volume with the name, or -1 if not found.
+func findVolume(volumes []corev1.Volume, name string) int {
+	for i, v := range volumes {
+		if v.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// findVolumeMount returns the index of the mount of the named volume at the
+// mount path, or -1 if not found.
+func findVolumeMount(mounts []corev1.VolumeMount, name, mountPath string) int {
+	for i, m := range mounts {
+		if m.Name == name && m.MountPath == mountPath {
+			return i
+		}
+	}
+	return -1
+}
+
 func FindString(slice []string, val string) (int, bool) {
 	for i, item := range slice {
 		if item == val {
@@ -0,0 +1,429 @@
+package injector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	"github.com/oam-dev/trait-injector/pkg/plugin"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// makeProjectedVolumeName returns the name of the projected volume merging
+// the sources mounted at the path.
+func makeProjectedVolumeName(mountPath string) string {
+	sum := sha256.Sum256([]byte(mountPath))
+	return "projected-" + hex.EncodeToString(sum[:])[:10]
+}
+
+// needsProjection tells whether the binding is mounted through the projected
+// volume of its mount path even if no other source is mounted there.
+func needsProjection(b *corev1alpha1.Binding) bool {
+	return b.From.DownwardAPI != nil || b.From.ServiceAccountToken != nil || len(b.To.SubDirectory) != 0
+}
+
+// makeVolumeMount returns the mount of the named volume at the mount path,
+// with the sub-path and read-only flag of the binding.
+func makeVolumeMount(b *corev1alpha1.Binding, name, mountPath string) corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      name,
+		MountPath: mountPath,
+		SubPath:   b.To.SubPath,
+		ReadOnly:  b.To.ReadOnly,
+	}
+}
+
+// makeProjection returns the projection of the data source of the binding,
+// with its items under the sub-directory of the binding.
+func makeProjection(b *corev1alpha1.Binding, secretName, configMapName string) (corev1.VolumeProjection, error) {
+	dir := b.To.SubDirectory
+	switch {
+	case b.From.DownwardAPI != nil:
+		items := make([]corev1.DownwardAPIVolumeFile, 0, len(b.From.DownwardAPI.Items))
+		for _, item := range b.From.DownwardAPI.Items {
+			item.Path = path.Join(dir, item.Path)
+			items = append(items, item)
+		}
+		return corev1.VolumeProjection{
+			DownwardAPI: &corev1.DownwardAPIProjection{
+				Items: items,
+			},
+		}, nil
+	case b.From.ServiceAccountToken != nil:
+		t := b.From.ServiceAccountToken
+		return corev1.VolumeProjection{
+			ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+				Audience:          t.Audience,
+				ExpirationSeconds: t.ExpirationSeconds,
+				Path:              path.Join(dir, t.Path),
+			},
+		}, nil
+	}
+
+	if len(dir) != 0 && len(b.To.Items) == 0 {
+		return corev1.VolumeProjection{}, errors.New("subDirectory requires items for secret and configMap sources")
+	}
+	var items []corev1.KeyToPath
+	for _, item := range b.To.Items {
+		item.Path = path.Join(dir, item.Path)
+		items = append(items, item)
+	}
+	switch {
+	case len(secretName) != 0:
+		return corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+				Items: items,
+			},
+		}, nil
+	case len(configMapName) != 0:
+		return corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: configMapName,
+				},
+				Items: items,
+			},
+		}, nil
+	}
+	return corev1.VolumeProjection{}, errors.New("only secret, configMap, downwardAPI and serviceAccountToken sources can be projected")
+}
+
+// volumeProjection returns the projection of the source of the volume, if it
+// can be merged into a projected volume.
+func volumeProjection(v corev1.Volume) (corev1.VolumeProjection, bool) {
+	switch {
+	case v.Secret != nil:
+		return corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: v.Secret.SecretName,
+				},
+				Items:    v.Secret.Items,
+				Optional: v.Secret.Optional,
+			},
+		}, true
+	case v.ConfigMap != nil:
+		return corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: v.ConfigMap.LocalObjectReference,
+				Items:                v.ConfigMap.Items,
+				Optional:             v.ConfigMap.Optional,
+			},
+		}, true
+	case v.DownwardAPI != nil:
+		return corev1.VolumeProjection{
+			DownwardAPI: &corev1.DownwardAPIProjection{
+				Items: v.DownwardAPI.Items,
+			},
+		}, true
+	}
+	return corev1.VolumeProjection{}, false
+}
+
+// sameProjection tells whether the projections are of the same source: the
+// same secret or configmap, the same files of the downward API or the same
+// service account token file.
+func sameProjection(a, b corev1.VolumeProjection) bool {
+	switch {
+	case a.Secret != nil && b.Secret != nil:
+		return a.Secret.Name == b.Secret.Name
+	case a.ConfigMap != nil && b.ConfigMap != nil:
+		return a.ConfigMap.Name == b.ConfigMap.Name
+	case a.DownwardAPI != nil && b.DownwardAPI != nil:
+		if len(a.DownwardAPI.Items) != len(b.DownwardAPI.Items) {
+			return false
+		}
+		for i := range a.DownwardAPI.Items {
+			if a.DownwardAPI.Items[i].Path != b.DownwardAPI.Items[i].Path {
+				return false
+			}
+		}
+		return true
+	case a.ServiceAccountToken != nil && b.ServiceAccountToken != nil:
+		return a.ServiceAccountToken.Path == b.ServiceAccountToken.Path
+	}
+	return false
+}
+
+// sameProjectionItems tells whether the projections of the same source
+// project the same items.
+func sameProjectionItems(a, b corev1.VolumeProjection) bool {
+	switch {
+	case a.Secret != nil:
+		return sameKeyToPaths(a.Secret.Items, b.Secret.Items)
+	case a.ConfigMap != nil:
+		return sameKeyToPaths(a.ConfigMap.Items, b.ConfigMap.Items)
+	}
+	return true
+}
+
+// findProjection returns the index of the projection of the same source, or
+// -1 if not found.
+func findProjection(sources []corev1.VolumeProjection, p corev1.VolumeProjection) int {
+	for i, s := range sources {
+		if sameProjection(s, p) {
+			return i
+		}
+	}
+	return -1
+}
+
+// findMountPath returns the index of the mount at the path, or -1 if not found.
+func findMountPath(mounts []corev1.VolumeMount, mountPath string) int {
+	for i, m := range mounts {
+		if m.MountPath == mountPath {
+			return i
+		}
+	}
+	return -1
+}
+
+// isSelected tells whether the container is selected by the binding.
+func isSelected(b *corev1alpha1.Binding, c corev1.Container) bool {
+	if s := b.ContainerSelector; s != nil {
+		_, ok := FindString(s.ByNames, c.Name)
+		return ok
+	}
+	return true
+}
+
+// isMounted tells whether the named volume is mounted by a container of the
+// pod spec.
+func isMounted(spec *corev1.PodSpec, name string) bool {
+	for _, c := range spec.Containers {
+		for _, m := range c.VolumeMounts {
+			if m.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// injectVolumes mounts the data source of the binding into the selected
+// containers of the pod spec, recording the volumes and mounts injected. The
+// source gets a volume of its own, unless another volume is mounted at the
+// same path or the binding needs a projection: then the sources mounted at
+// the path are merged into the projected volume of the path, and the volumes
+// they came from are removed once no longer mounted.
+func injectVolumes(spec *corev1.PodSpec, b *corev1alpha1.Binding, secretName, configMapName, pvcName string, r *record) error {
+	volumeName := makeVolumeMountName(secretName, configMapName, pvcName)
+	projected := needsProjection(b)
+	if projected && len(b.To.SubPath) != 0 {
+		return errors.New("subPath cannot be used with subDirectory, downwardAPI or serviceAccountToken")
+	}
+
+	// containers mounting the volume of the source, and the mount paths
+	// of the containers merging it into a projected volume
+	var plain []int
+	var mergedPaths []string
+	merged := map[string][]int{}
+	for i, c := range spec.Containers {
+		if !isSelected(b, c) {
+			continue
+		}
+		mountPath := makeMountPath(b, c)
+		j := findMountPath(c.VolumeMounts, mountPath)
+		switch {
+		case !projected && j < 0:
+			plain = append(plain, i)
+		case !projected && c.VolumeMounts[j].Name == volumeName:
+			// already in place, replaced if injected and its options
+			// changed, or else left to the workload
+			if old := c.VolumeMounts[j]; hasVolumeMount(r.owners(), c.Name, old) {
+				mount := makeVolumeMount(b, volumeName, mountPath)
+				spec.Containers[i].VolumeMounts[j] = mount
+				r.removeVolumeMount(c.Name, old)
+				r.addVolumeMount(c.Name, mount)
+			}
+		case j >= 0 && (len(b.To.SubPath) != 0 || len(c.VolumeMounts[j].SubPath) != 0):
+			// files mounted with a sub-path cannot be merged
+			return fmt.Errorf("mount path %s of container %s is taken by volume %s", mountPath, c.Name, c.VolumeMounts[j].Name)
+		default:
+			if _, ok := merged[mountPath]; !ok {
+				mergedPaths = append(mergedPaths, mountPath)
+			}
+			merged[mountPath] = append(merged[mountPath], i)
+		}
+	}
+
+	if !projected && (len(plain) != 0 || findVolume(spec.Volumes, volumeName) >= 0) {
+		volume := corev1.Volume{
+			Name:         volumeName,
+			VolumeSource: makeVolumeSource(b.To, secretName, configMapName, pvcName),
+		}
+		switch k := findVolume(spec.Volumes, volumeName); {
+		case k < 0:
+			spec.Volumes = append(spec.Volumes, volume)
+			r.addVolume(volumeName)
+		case hasVolume(r.owners(), volumeName):
+			// already injected, replaced if its source differs
+			if !sameVolumeSource(spec.Volumes[k].VolumeSource, volume.VolumeSource) {
+				spec.Volumes[k] = volume
+			}
+			r.addVolume(volumeName)
+		}
+		for _, i := range plain {
+			c := &spec.Containers[i]
+			mount := makeVolumeMount(b, volumeName, makeMountPath(b, *c))
+			c.VolumeMounts = append(c.VolumeMounts, mount)
+			r.addVolumeMount(c.Name, mount)
+		}
+	}
+
+	converted := map[string]bool{}
+	for _, mountPath := range mergedPaths {
+		projection, err := makeProjection(b, secretName, configMapName)
+		if err != nil {
+			return fmt.Errorf("mount path %s: %w", mountPath, err)
+		}
+		name := makeProjectedVolumeName(mountPath)
+
+		// the sources already mounted at the path, recorded as injected if
+		// their volume was
+		sources := []corev1.VolumeProjection{}
+		for _, i := range merged[mountPath] {
+			c := spec.Containers[i]
+			j := findMountPath(c.VolumeMounts, mountPath)
+			if j < 0 || c.VolumeMounts[j].Name == name {
+				continue
+			}
+			from := c.VolumeMounts[j].Name
+			k := findVolume(spec.Volumes, from)
+			if k < 0 {
+				return fmt.Errorf("mount path %s of container %s refers to missing volume %s", mountPath, c.Name, from)
+			}
+			p, ok := volumeProjection(spec.Volumes[k])
+			if !ok {
+				return fmt.Errorf("mount path %s of container %s is taken by volume %s which cannot be projected", mountPath, c.Name, from)
+			}
+			if findProjection(sources, p) < 0 && !sameProjection(p, projection) {
+				sources = append(sources, p)
+				if hasVolume([]*plugin.Injection{r.injected}, from) {
+					r.addProjection(name, p)
+				}
+			}
+			converted[from] = true
+		}
+
+		// then the binding's
+		if k := findVolume(spec.Volumes, name); k >= 0 {
+			v := spec.Volumes[k].Projected
+			for _, p := range sources {
+				if findProjection(v.Sources, p) < 0 {
+					v.Sources = append(v.Sources, p)
+				}
+			}
+			switch s := findProjection(v.Sources, projection); {
+			case s < 0:
+				v.Sources = append(v.Sources, projection)
+				r.addProjection(name, projection)
+			case hasProjection(r.owners(), name, projection):
+				if !sameProjectionItems(v.Sources[s], projection) {
+					v.Sources[s] = projection
+				}
+				r.addProjection(name, projection)
+			}
+			if hasVolume(r.owners(), name) {
+				r.addVolume(name)
+			}
+		} else {
+			spec.Volumes = append(spec.Volumes, corev1.Volume{
+				Name: name,
+				VolumeSource: corev1.VolumeSource{
+					Projected: &corev1.ProjectedVolumeSource{
+						Sources:     append(sources, projection),
+						DefaultMode: b.To.DefaultMode,
+					},
+				},
+			})
+			r.addProjection(name, projection)
+			r.addVolume(name)
+		}
+
+		for _, i := range merged[mountPath] {
+			c := &spec.Containers[i]
+			mount := makeVolumeMount(b, name, mountPath)
+			switch j := findMountPath(c.VolumeMounts, mountPath); {
+			case j < 0:
+				c.VolumeMounts = append(c.VolumeMounts, mount)
+				r.addVolumeMount(c.Name, mount)
+			case c.VolumeMounts[j].Name == name:
+				if hasVolumeMount(r.owners(), c.Name, c.VolumeMounts[j]) {
+					r.addVolumeMount(c.Name, c.VolumeMounts[j])
+				}
+			default:
+				// the mount of a merged volume, recorded as injected if it was
+				old := c.VolumeMounts[j]
+				c.VolumeMounts[j].Name = name
+				if hasVolumeMount([]*plugin.Injection{r.injected}, c.Name, old) {
+					r.removeVolumeMount(c.Name, old)
+					r.addVolumeMount(c.Name, c.VolumeMounts[j])
+				}
+			}
+		}
+	}
+
+	// remove the volumes merged into projected volumes unless mounted elsewhere
+	var volumes []corev1.Volume
+	for _, v := range spec.Volumes {
+		if !converted[v.Name] || isMounted(spec, v.Name) {
+			volumes = append(volumes, v)
+		}
+	}
+	spec.Volumes = volumes
+	return nil
+}
+
+// uninjectVolumes removes the volumes and projected sources recorded in the
+// injection from the pod spec, but the ones shared with other injections.
+// The volumes are kept if mounted still, and projected volumes are removed
+// along with their last source.
+func uninjectVolumes(spec *corev1.PodSpec, in *plugin.Injection, shared []*plugin.Injection) {
+	removed := map[string]bool{}
+	for name, sources := range in.Projections {
+		k := findVolume(spec.Volumes, name)
+		if k < 0 || spec.Volumes[k].Projected == nil {
+			continue
+		}
+		v := spec.Volumes[k].Projected
+		for _, p := range sources {
+			if s := findProjection(v.Sources, p); s >= 0 && !hasProjection(shared, name, p) {
+				v.Sources = append(v.Sources[:s], v.Sources[s+1:]...)
+			}
+		}
+		if len(v.Sources) == 0 {
+			// the last source, unmounted from every container
+			removed[name] = true
+		}
+	}
+	for _, name := range in.Volumes {
+		if !hasVolume(shared, name) && !isMounted(spec, name) {
+			removed[name] = true
+		}
+	}
+
+	for i := range spec.Containers {
+		c := &spec.Containers[i]
+		var mounts []corev1.VolumeMount
+		for _, m := range c.VolumeMounts {
+			if !removed[m.Name] {
+				mounts = append(mounts, m)
+			}
+		}
+		c.VolumeMounts = mounts
+	}
+	var volumes []corev1.Volume
+	for _, v := range spec.Volumes {
+		if !removed[v.Name] {
+			volumes = append(volumes, v)
+		}
+	}
+	spec.Volumes = volumes
+}
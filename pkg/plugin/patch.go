@@ -0,0 +1,259 @@
+package plugin
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// CreatePatch returns the JSON patch turning the value at the JSON pointer of
+// the raw workload into the modified one. The original value is the value at
+// the pointer as decoded by the target injector, and the modified value a
+// mutated copy of it: target injectors mutate a copy of the part of the
+// workload they inject rather than writing the patch operations themselves.
+//
+// The patch is made against the raw workload, so that the fields the decoded
+// types do not know are kept, and array elements are matched by name, and
+// mount path for volume mounts, rather than by index, so that removing an
+// element does not patch the next ones into it.
+func CreatePatch(raw []byte, pointer string, original, modified interface{}) ([]webhook.JSONPatchOp, error) {
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	a, err := toJSONValue(original)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toJSONValue(modified)
+	if err != nil {
+		return nil, err
+	}
+	if reflect.DeepEqual(a, b) {
+		return nil, nil
+	}
+
+	value, found := lookupJSONPointer(doc, pointer)
+	if !found {
+		return []webhook.JSONPatchOp{{Operation: "add", Path: pointer, Value: mergeJSON(nil, a, b)}}, nil
+	}
+	return diffJSON(pointer, value, mergeJSON(value, a, b)), nil
+}
+
+// EscapeJSONPointer escapes a reference token of a JSON pointer.
+func EscapeJSONPointer(s string) string {
+	return strings.Replace(strings.Replace(s, "~", "~0", -1), "/", "~1", -1)
+}
+
+// unescapeJSONPointer unescapes a reference token of a JSON pointer.
+func unescapeJSONPointer(s string) string {
+	return strings.Replace(strings.Replace(s, "~1", "/", -1), "~0", "~", -1)
+}
+
+// toJSONValue returns the value as decoded from JSON into interface{}.
+func toJSONValue(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// lookupJSONPointer returns the value at the JSON pointer of the document.
+func lookupJSONPointer(doc interface{}, pointer string) (interface{}, bool) {
+	if len(pointer) == 0 {
+		return doc, true
+	}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if doc, ok = v[unescapeJSONPointer(token)]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			doc = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// mergeJSON returns the raw value with the changes turning the original value,
+// the raw one as decoded, into the modified one. The fields of the raw value
+// the original one does not have are kept.
+func mergeJSON(raw, original, modified interface{}) interface{} {
+	if reflect.DeepEqual(original, modified) && raw != nil {
+		return raw
+	}
+	switch m := modified.(type) {
+	case map[string]interface{}:
+		o, _ := original.(map[string]interface{})
+		r, _ := raw.(map[string]interface{})
+		merged := make(map[string]interface{}, len(r)+len(m))
+		for k, v := range r {
+			merged[k] = v
+		}
+		for k := range o {
+			if _, ok := m[k]; !ok {
+				delete(merged, k)
+			}
+		}
+		for k, v := range m {
+			rv, ok := r[k]
+			if !ok && reflect.DeepEqual(o[k], v) {
+				// a default the raw value leaves out
+				continue
+			}
+			merged[k] = mergeJSON(rv, o[k], v)
+		}
+		return merged
+	case []interface{}:
+		o, _ := original.([]interface{})
+		r, _ := raw.([]interface{})
+		if len(r) != len(o) {
+			return m
+		}
+		merged := make([]interface{}, len(m))
+		for i, j := range matchElements(o, m) {
+			if j < 0 {
+				merged[i] = m[i]
+			} else {
+				merged[i] = mergeJSON(r[j], o[j], m[i])
+			}
+		}
+		return merged
+	}
+	return modified
+}
+
+// matchElements returns the indexes of the elements of a matching the
+// elements of b, -1 for the ones matching none.
+func matchElements(a, b []interface{}) []int {
+	matched := make([]int, len(b))
+	used := make([]bool, len(a))
+	for i, v := range b {
+		matched[i] = -1
+		for j, w := range a {
+			if !used[j] && sameElement(w, v) {
+				matched[i] = j
+				used[j] = true
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// sameElement tells whether the array elements are the same element, maybe
+// modified: objects having a name are matched by name, and mount path if
+// any, other values by value.
+func sameElement(a, b interface{}) bool {
+	am, ok := a.(map[string]interface{})
+	bm, ok2 := b.(map[string]interface{})
+	if ok && ok2 {
+		if name, ok := am["name"]; ok {
+			return reflect.DeepEqual(name, bm["name"]) && reflect.DeepEqual(am["mountPath"], bm["mountPath"])
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// diffJSON returns the patch turning the value at the JSON pointer into the
+// modified one, in the order of the object keys.
+func diffJSON(pointer string, value, modified interface{}) []webhook.JSONPatchOp {
+	if reflect.DeepEqual(value, modified) {
+		return nil
+	}
+	switch m := modified.(type) {
+	case map[string]interface{}:
+		v, ok := value.(map[string]interface{})
+		if !ok {
+			break
+		}
+		var patches []webhook.JSONPatchOp
+		for _, k := range sortedKeys(v) {
+			if _, ok := m[k]; !ok {
+				patches = append(patches, webhook.JSONPatchOp{Operation: "remove", Path: pointer + "/" + EscapeJSONPointer(k)})
+			}
+		}
+		for _, k := range sortedKeys(m) {
+			p := pointer + "/" + EscapeJSONPointer(k)
+			if vv, ok := v[k]; ok {
+				patches = append(patches, diffJSON(p, vv, m[k])...)
+			} else {
+				patches = append(patches, webhook.JSONPatchOp{Operation: "add", Path: p, Value: m[k]})
+			}
+		}
+		return patches
+	case []interface{}:
+		v, ok := value.([]interface{})
+		if !ok {
+			break
+		}
+		if patches, ok := diffJSONArray(pointer, v, m); ok {
+			return patches
+		}
+	}
+	return []webhook.JSONPatchOp{{Operation: "replace", Path: pointer, Value: modified}}
+}
+
+// diffJSONArray returns the patch turning the array at the JSON pointer into
+// the modified one: the elements matching none are removed, the new ones added
+// at their index, then the matching ones patched. It fails if the matching
+// elements are reordered.
+func diffJSONArray(pointer string, value, modified []interface{}) ([]webhook.JSONPatchOp, bool) {
+	matched := matchElements(value, modified)
+	kept := make([]bool, len(value))
+	last := -1
+	for _, j := range matched {
+		if j < 0 {
+			continue
+		}
+		if j < last {
+			return nil, false
+		}
+		kept[j] = true
+		last = j
+	}
+
+	var patches []webhook.JSONPatchOp
+	for j := len(value) - 1; j >= 0; j-- {
+		if !kept[j] {
+			patches = append(patches, webhook.JSONPatchOp{Operation: "remove", Path: pointer + "/" + strconv.Itoa(j)})
+		}
+	}
+	for i, j := range matched {
+		if j < 0 {
+			patches = append(patches, webhook.JSONPatchOp{Operation: "add", Path: pointer + "/" + strconv.Itoa(i), Value: modified[i]})
+		}
+	}
+	for i, j := range matched {
+		if j >= 0 {
+			patches = append(patches, diffJSON(pointer+"/"+strconv.Itoa(i), value[j], modified[i])...)
+		}
+	}
+	return patches, true
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
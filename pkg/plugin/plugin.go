@@ -3,16 +3,12 @@ package plugin
 import (
 	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
-var TargetInjectors []TargetInjector
-
-func RegisterTargetInjectors(ts ...TargetInjector) {
-	TargetInjectors = append(TargetInjectors, ts...)
-}
-
 // TargetInjector handles data injection to workload target.
 type TargetInjector interface {
 	Name() string
@@ -20,9 +16,116 @@ type TargetInjector interface {
 	Match(*admissionv1beta1.AdmissionRequest, *corev1alpha1.WorkloadReference) bool
 
 	Inject(TargetContext, runtime.RawExtension) ([]webhook.JSONPatchOp, error)
+
+	// Uninject returns the patches removing the entries recorded in the
+	// Injected of the context, but the ones shared with other ServiceBindings.
+	Uninject(TargetContext, runtime.RawExtension) ([]webhook.JSONPatchOp, error)
+}
+
+// KindLister is implemented by target injectors of the workloads of known
+// kinds, for the Registry to look them up by kind. Target injectors that do
+// not implement it are looked up for every kind.
+type KindLister interface {
+	// Kinds returns the kinds of the workloads injected.
+	Kinds() []schema.GroupVersionKind
+}
+
+// PodSpecReader is implemented by target injectors of workloads whose pod spec
+// is not at spec.template.spec.
+type PodSpecReader interface {
+	// PodSpec returns the pod spec of the workload.
+	PodSpec(runtime.RawExtension) (*corev1.PodSpec, error)
 }
 
 type TargetContext struct {
 	Binding *corev1alpha1.Binding
 	Values  map[string]interface{}
+
+	// Injected records the entries injected for the ServiceBinding of the
+	// binding: Inject adds the entries it injects, and Uninject removes the
+	// recorded ones.
+	Injected *Injection
+
+	// Shared are the entries injected for the other ServiceBindings of the
+	// workload. Inject records the ones it needs that are already in place as
+	// shared, and Uninject keeps them.
+	Shared []*Injection
+}
+
+// Injection records the entries injected into the pod spec of a workload for
+// a ServiceBinding, so that they are removed exactly, leaving the entries of
+// the workload's own alone even if alike.
+type Injection struct {
+	// Containers are the entries injected into the containers, by name.
+	Containers map[string]*ContainerInjection `json:"containers,omitempty"`
+
+	// Volumes are the names of the volumes injected, and Projections the
+	// sources injected into projected volumes, by volume name.
+	Volumes     []string                             `json:"volumes,omitempty"`
+	Projections map[string][]corev1.VolumeProjection `json:"projections,omitempty"`
+
+	// Annotations are the keys of the pod template annotations injected.
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+// ContainerInjection records the entries injected into a container.
+type ContainerInjection struct {
+	EnvFrom      []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	Env          []corev1.EnvVar        `json:"env,omitempty"`
+	VolumeMounts []corev1.VolumeMount   `json:"volumeMounts,omitempty"`
+}
+
+// DeepCopy returns a copy of the injection.
+func (in *Injection) DeepCopy() *Injection {
+	if in == nil {
+		return nil
+	}
+	out := &Injection{
+		Volumes:     append([]string(nil), in.Volumes...),
+		Annotations: append([]string(nil), in.Annotations...),
+	}
+	if in.Containers != nil {
+		out.Containers = make(map[string]*ContainerInjection, len(in.Containers))
+		for name, c := range in.Containers {
+			out.Containers[name] = c.DeepCopy()
+		}
+	}
+	if in.Projections != nil {
+		out.Projections = make(map[string][]corev1.VolumeProjection, len(in.Projections))
+		for name, sources := range in.Projections {
+			l := make([]corev1.VolumeProjection, len(sources))
+			for i := range sources {
+				sources[i].DeepCopyInto(&l[i])
+			}
+			out.Projections[name] = l
+		}
+	}
+	return out
+}
+
+// DeepCopy returns a copy of the entries injected into a container.
+func (in *ContainerInjection) DeepCopy() *ContainerInjection {
+	if in == nil {
+		return nil
+	}
+	out := &ContainerInjection{}
+	if in.EnvFrom != nil {
+		out.EnvFrom = make([]corev1.EnvFromSource, len(in.EnvFrom))
+		for i := range in.EnvFrom {
+			in.EnvFrom[i].DeepCopyInto(&out.EnvFrom[i])
+		}
+	}
+	if in.Env != nil {
+		out.Env = make([]corev1.EnvVar, len(in.Env))
+		for i := range in.Env {
+			in.Env[i].DeepCopyInto(&out.Env[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		out.VolumeMounts = make([]corev1.VolumeMount, len(in.VolumeMounts))
+		for i := range in.VolumeMounts {
+			in.VolumeMounts[i].DeepCopyInto(&out.VolumeMounts[i])
+		}
+	}
+	return out
 }
@@ -0,0 +1,164 @@
+package plugin
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func TestPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Plugin Suite")
+}
+
+// testInjector matches the workloads of its name.
+type testInjector struct {
+	name string
+}
+
+func (ti *testInjector) Name() string {
+	return ti.name
+}
+
+func (ti *testInjector) Match(req *admissionv1beta1.AdmissionRequest, w *corev1alpha1.WorkloadReference) bool {
+	return req.Name == w.Name
+}
+
+func (ti *testInjector) Inject(TargetContext, runtime.RawExtension) ([]webhook.JSONPatchOp, error) {
+	return nil, nil
+}
+
+func (ti *testInjector) Uninject(TargetContext, runtime.RawExtension) ([]webhook.JSONPatchOp, error) {
+	return nil, nil
+}
+
+// testKindInjector matches the workloads of its name and kinds.
+type testKindInjector struct {
+	testInjector
+	kinds []schema.GroupVersionKind
+}
+
+func (ti *testKindInjector) Kinds() []schema.GroupVersionKind {
+	return ti.kinds
+}
+
+var _ = Describe("Registry", func() {
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	job := schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}
+
+	var r *Registry
+	var deploymentInjector, jobInjector, anyInjector TargetInjector
+	BeforeEach(func() {
+		r = NewRegistry()
+		deploymentInjector = &testKindInjector{testInjector{"deployment"}, []schema.GroupVersionKind{deployment}}
+		jobInjector = &testKindInjector{testInjector{"job"}, []schema.GroupVersionKind{job}}
+		anyInjector = &testInjector{"any"}
+		Expect(r.Register(anyInjector, deploymentInjector, jobInjector)).To(Succeed())
+	})
+
+	It("should look up target injectors by kind", func() {
+		Expect(r.Lookup(deployment)).To(Equal([]TargetInjector{deploymentInjector, anyInjector}))
+		Expect(r.Lookup(job)).To(Equal([]TargetInjector{jobInjector, anyInjector}))
+		Expect(r.Lookup(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"})).To(Equal([]TargetInjector{anyInjector}))
+		Expect(r.Injectors()).To(Equal([]TargetInjector{anyInjector, deploymentInjector, jobInjector}))
+	})
+
+	It("should find the target injector matching the request", func() {
+		req := &admissionv1beta1.AdmissionRequest{
+			Kind: metav1.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"},
+			Name: "test-job",
+		}
+		Expect(r.Find(req, &corev1alpha1.WorkloadReference{Name: "test-job"})).To(Equal(jobInjector))
+		Expect(r.Find(req, &corev1alpha1.WorkloadReference{Name: "other-job"})).To(BeNil())
+	})
+
+	It("should reject target injectors registered twice", func() {
+		err := r.Register(&testInjector{"job"})
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("target injector job already registered"))
+
+		Expect(r.Register(&testInjector{"other"}, &testInjector{"other"})).NotTo(Succeed())
+		Expect(r.Injectors()).To(HaveLen(3))
+		Expect(r.Enabled("other")).To(BeFalse())
+	})
+
+	It("should skip disabled target injectors", func() {
+		Expect(r.Disable("deployment", "any")).To(Succeed())
+		Expect(r.Enabled("deployment")).To(BeFalse())
+		Expect(r.Enabled("job")).To(BeTrue())
+		Expect(r.Lookup(deployment)).To(BeEmpty())
+		Expect(r.Lookup(job)).To(Equal([]TargetInjector{jobInjector}))
+
+		Expect(r.Enable("any")).To(Succeed())
+		Expect(r.Lookup(deployment)).To(Equal([]TargetInjector{anyInjector}))
+
+		err := r.Disable("job", "unknown")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(ContainSubstring("target injector unknown not registered"))
+		Expect(r.Enabled("job")).To(BeTrue())
+	})
+
+	It("should keep registries apart", func() {
+		other := NewRegistry()
+		Expect(other.Register(&testInjector{"job"})).To(Succeed())
+		Expect(other.Injectors()).To(HaveLen(1))
+		Expect(r.Injectors()).To(HaveLen(3))
+	})
+})
+
+var _ = Describe("CreatePatch", func() {
+	raw := []byte(`{"spec":{"volumes":[
+		{"name":"secret-db","secret":{"secretName":"db"}},
+		{"name":"scratch","ephemeral":{"volumeClaimTemplate":{}}}
+	]}}`)
+	original := corev1.PodSpec{Volumes: []corev1.Volume{{
+		Name:         "secret-db",
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "db"}},
+	}, {
+		Name: "scratch",
+	}}}
+
+	It("should match array elements by name and keep unknown fields", func() {
+		modified := original.DeepCopy()
+		modified.Volumes = modified.Volumes[1:]
+		modified.Volumes = append(modified.Volumes, corev1.Volume{
+			Name:         "cache",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+		patches, err := CreatePatch(raw, "/spec", &original, modified)
+		Expect(err).To(BeNil())
+		Expect(patches).To(Equal([]webhook.JSONPatchOp{{
+			Operation: "remove",
+			Path:      "/spec/volumes/0",
+		}, {
+			Operation: "add",
+			Path:      "/spec/volumes/1",
+			Value:     map[string]interface{}{"name": "cache", "emptyDir": map[string]interface{}{}},
+		}}))
+	})
+
+	It("should add the value at a JSON pointer missing from the workload", func() {
+		type metadata struct {
+			Annotations map[string]string `json:"annotations,omitempty"`
+		}
+		patches, err := CreatePatch(raw, "/spec/metadata", metadata{}, metadata{map[string]string{"a~/b": "c"}})
+		Expect(err).To(BeNil())
+		Expect(patches).To(Equal([]webhook.JSONPatchOp{{
+			Operation: "add",
+			Path:      "/spec/metadata",
+			Value:     map[string]interface{}{"annotations": map[string]interface{}{"a~/b": "c"}},
+		}}))
+
+		patches, err = CreatePatch(raw, "/spec/metadata", metadata{}, metadata{})
+		Expect(err).To(BeNil())
+		Expect(patches).To(BeEmpty())
+	})
+})
@@ -0,0 +1,137 @@
+package plugin
+
+import (
+	"fmt"
+	"sync"
+
+	corev1alpha1 "github.com/oam-dev/trait-injector/api/v1alpha1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Registry holds the target injectors by name, and by the kinds of the
+// workloads they inject if they implement KindLister. Target injectors can be
+// disabled by name. A Registry is safe for concurrent use.
+type Registry struct {
+	mu sync.RWMutex
+
+	// target injectors in registration order, by kind, and the ones that do
+	// not list their kinds
+	injectors []TargetInjector
+	kinds     map[schema.GroupVersionKind][]TargetInjector
+	anyKind   []TargetInjector
+
+	names    map[string]bool
+	disabled map[string]bool
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		kinds:    map[schema.GroupVersionKind][]TargetInjector{},
+		names:    map[string]bool{},
+		disabled: map[string]bool{},
+	}
+}
+
+// Register adds the target injectors, enabled. None is added if the name of
+// one is already registered.
+func (r *Registry) Register(tis ...TargetInjector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := map[string]bool{}
+	for _, ti := range tis {
+		name := ti.Name()
+		if r.names[name] || names[name] {
+			return fmt.Errorf("target injector %s already registered", name)
+		}
+		names[name] = true
+	}
+	for _, ti := range tis {
+		r.injectors = append(r.injectors, ti)
+		r.names[ti.Name()] = true
+		l, ok := ti.(KindLister)
+		if !ok {
+			r.anyKind = append(r.anyKind, ti)
+			continue
+		}
+		for _, gvk := range l.Kinds() {
+			r.kinds[gvk] = append(r.kinds[gvk], ti)
+		}
+	}
+	return nil
+}
+
+// Enable enables the named target injectors.
+func (r *Registry) Enable(names ...string) error {
+	return r.setDisabled(names, false)
+}
+
+// Disable disables the named target injectors, so that they are no longer
+// looked up.
+func (r *Registry) Disable(names ...string) error {
+	return r.setDisabled(names, true)
+}
+
+func (r *Registry) setDisabled(names []string, disabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range names {
+		if !r.names[name] {
+			return fmt.Errorf("target injector %s not registered", name)
+		}
+	}
+	for _, name := range names {
+		r.disabled[name] = disabled
+	}
+	return nil
+}
+
+// Enabled tells whether the named target injector is registered and enabled.
+func (r *Registry) Enabled(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.names[name] && !r.disabled[name]
+}
+
+// Injectors returns the registered target injectors, enabled or not, in
+// registration order.
+func (r *Registry) Injectors() []TargetInjector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]TargetInjector(nil), r.injectors...)
+}
+
+// Lookup returns the enabled target injectors of the workloads of the kind,
+// in registration order: the ones listing the kind, then the ones that do not
+// list their kinds.
+func (r *Registry) Lookup(gvk schema.GroupVersionKind) []TargetInjector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tis []TargetInjector
+	for _, l := range [][]TargetInjector{r.kinds[gvk], r.anyKind} {
+		for _, ti := range l {
+			if !r.disabled[ti.Name()] {
+				tis = append(tis, ti)
+			}
+		}
+	}
+	return tis
+}
+
+// Find returns the first enabled target injector of the kind of the request
+// that matches the request and the workload, or nil if none does.
+func (r *Registry) Find(req *admissionv1beta1.AdmissionRequest, w *corev1alpha1.WorkloadReference) TargetInjector {
+	k := req.Kind
+	for _, ti := range r.Lookup(schema.GroupVersionKind{Group: k.Group, Version: k.Version, Kind: k.Kind}) {
+		if ti.Match(req, w) {
+			return ti
+		}
+	}
+	return nil
+}